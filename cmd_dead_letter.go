@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/b-harvest/metisian/metis"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const deadLetterUsage = `usage: metisian dead-letter <list|replay> [flags]
+
+  list                 show the notifications which couldn't be delivered, read from --file
+  replay [id...]       queue them again in a running metisian, all of them when no id is given
+
+alarms resolved in the meantime are dropped on replay instead of being sent.
+`
+
+// deadLetterCmd inspects the dead-letter file, and replays it through the dashboard api of a running monitor so
+// that delivered alarms are tracked (and later resolved) like any other.
+func deadLetterCmd(args []string) int {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, deadLetterUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("dead-letter "+args[0], flag.ExitOnError)
+	file := fs.String("file", metis.DefaultDeadLetterFile, "dead-letter file, as set by notification_retry.dead_letter_file")
+	dashUrl := fs.String("url", envOr("METISIAN_URL", "http://localhost:8888"), "url of the metisian dashboard, also set through env METISIAN_URL")
+	token := fs.String("token", os.Getenv("METISIAN_TOKEN"), "bearer token of a dashboard operator, also set through env METISIAN_TOKEN")
+	asJson := fs.Bool("json", false, "print the dead letters as json")
+	_ = fs.Parse(args[1:])
+
+	switch args[0] {
+	case "list":
+		letters, err := metis.ReadDeadLetters(*file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if *asJson {
+			b, _ := json.MarshalIndent(letters, "", "  ")
+			fmt.Println(string(b))
+			return 0
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCREATED\tDESTINATION\tSEQUENCER\tRESOLVED\tATTEMPTS\tMESSAGE\tLAST ERROR")
+		for _, d := range letters {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\t%d\t%s\t%s\n", d.Id, d.Created.UTC().Format(time.RFC3339), d.Notifier,
+				d.Sequencer, d.Resolved, d.Attempts, oneLine(d.Message), oneLine(d.LastError))
+		}
+		_ = w.Flush()
+		return 0
+
+	case "replay":
+		body, _ := json.Marshal(map[string][]string{"ids": fs.Args()})
+		req, err := http.NewRequest(http.MethodPost, strings.TrimRight(*dashUrl, "/")+"/dead-letters/replay", bytes.NewBuffer(body))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		req.Header.Set("Content-Type", "application/json")
+		setToken(req, *token)
+		resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		var out bytes.Buffer
+		if json.Indent(&out, b, "", "  ") == nil {
+			b = out.Bytes()
+		}
+		fmt.Println(string(b))
+		if resp.StatusCode >= 300 {
+			return 1
+		}
+		return 0
+
+	default:
+		fmt.Fprint(os.Stderr, deadLetterUsage)
+		return 2
+	}
+}
+
+func oneLine(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	if len(s) > 60 {
+		return s[:57] + "..."
+	}
+	return s
+}
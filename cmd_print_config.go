@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/b-harvest/metisian/metis"
+	"os"
+)
+
+const printConfigUsage = `usage: metisian print-config [flags] [config]
+
+prints the configuration metisian runs with: the ${ENV} and file:// secrets resolved, the [[networks]] with the
+settings they inherit, and the settings left to their default omitted. secrets are redacted, so that the output can
+be attached to a support ticket.
+`
+
+// printConfigCmd prints the effective configuration, with its secrets redacted.
+func printConfigCmd(args []string) int {
+	fs := flag.NewFlagSet("print-config", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, printConfigUsage)
+		fs.PrintDefaults()
+	}
+	path := fs.String("config", envOr("CONFIG_FILE_PATH", "config.toml"), "configuration toml file path or url, also set through env CONFIG_FILE_PATH")
+	token := fs.String("config-token", os.Getenv("CONFIG_TOKEN"), "bearer token of a remote configuration, also set through env CONFIG_TOKEN")
+	_ = fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	if fs.NArg() == 1 {
+		*path = fs.Arg(0)
+	}
+
+	cfg, err := metis.LoadConfig(*path, *token, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *path, err)
+		return 1
+	}
+	b, err := cfg.RedactedToml()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *path, err)
+		return 1
+	}
+	_, _ = os.Stdout.Write(b)
+	return 0
+}
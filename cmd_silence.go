@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const silenceUsage = `usage: metisian silence <list|add|delete> [flags]
+
+  list                 show the active silences
+  add                  create a silence
+  delete <id>          remove a silence
+
+silences are managed through the dashboard of a running metisian.
+`
+
+// silenceCmd manages the silences of a running monitor through the dashboard api.
+func silenceCmd(args []string) int {
+	if len(args) < 1 {
+		fmt.Fprint(os.Stderr, silenceUsage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("silence "+args[0], flag.ExitOnError)
+	dashUrl := fs.String("url", envOr("METISIAN_URL", "http://localhost:8888"), "url of the metisian dashboard, also set through env METISIAN_URL")
+	token := fs.String("token", os.Getenv("METISIAN_TOKEN"), "bearer token of a dashboard operator, also set through env METISIAN_TOKEN")
+	sequencer := fs.String("sequencer", "", "name of the sequencer to silence, every sequencer if empty")
+	alertId := fs.String("alert-id", "", "id of the alert to silence (ex. <address>consecutive or a node rpc url), every alert if empty")
+	duration := fs.String("duration", "", "how long the silence lasts, or the length of the maintenance window with --schedule (ex. 2h)")
+	until := fs.String("until", "", "RFC3339 time the silence ends, or the schedule ends with --schedule")
+	schedule := fs.String("schedule", "", `cron expression (UTC) starting a recurring maintenance window (ex. "0 3 * * 6")`)
+	comment := fs.String("comment", "", "why the alerts are silenced")
+	_ = fs.Parse(args[1:])
+
+	base := strings.TrimRight(*dashUrl, "/") + "/silences"
+	var (
+		req *http.Request
+		err error
+	)
+	switch args[0] {
+	case "list":
+		req, err = http.NewRequest(http.MethodGet, base, nil)
+
+	case "add":
+		s := map[string]interface{}{
+			"sequencer":  *sequencer,
+			"alert_id":   *alertId,
+			"duration":   *duration,
+			"schedule":   *schedule,
+			"comment":    *comment,
+			"created_by": "cli:" + envOr("USER", "unknown"),
+		}
+		if *until != "" {
+			t, e := time.Parse(time.RFC3339, *until)
+			if e != nil {
+				fmt.Fprintf(os.Stderr, "invalid --until: %v\n", e)
+				return 2
+			}
+			s["until"] = t
+		}
+		body, _ := json.Marshal(s)
+		req, err = http.NewRequest(http.MethodPost, base, bytes.NewBuffer(body))
+
+	case "delete":
+		if fs.NArg() != 1 {
+			fmt.Fprint(os.Stderr, silenceUsage)
+			return 2
+		}
+		req, err = http.NewRequest(http.MethodDelete, base+"/"+fs.Arg(0), nil)
+
+	default:
+		fmt.Fprint(os.Stderr, silenceUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	setToken(req, *token)
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+
+	var out bytes.Buffer
+	if json.Indent(&out, b, "", "  ") == nil {
+		b = out.Bytes()
+	}
+	if len(b) > 0 {
+		fmt.Println(string(b))
+	}
+	if resp.StatusCode >= 300 {
+		return 1
+	}
+	return 0
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// setToken authenticates a request to the dashboard.
+func setToken(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
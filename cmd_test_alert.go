@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/b-harvest/metisian/metis"
+	"os"
+	"sort"
+	"strings"
+)
+
+const testAlertUsage = `usage: metisian test-alert [flags] [config]
+
+sends a test alarm to the destinations of a configuration, and its resolution with --resolve, to check that they
+are set up. it exits with 1 when any destination failed.
+`
+
+// testAlertCmd sends a test alarm through the configured destinations.
+func testAlertCmd(args []string) int {
+	fs := flag.NewFlagSet("test-alert", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, testAlertUsage)
+		fs.PrintDefaults()
+	}
+	path := fs.String("config", envOr("CONFIG_FILE_PATH", "config.toml"), "configuration toml file path or url, also set through env CONFIG_FILE_PATH")
+	token := fs.String("config-token", os.Getenv("CONFIG_TOKEN"), "bearer token of a remote configuration, also set through env CONFIG_TOKEN")
+	destinations := fs.String("destination", "", "comma separated destinations to send to (ex. email,slack), every enabled one by default")
+	resolve := fs.Bool("resolve", false, "also send the resolution of the test alarm")
+	_ = fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	if fs.NArg() == 1 {
+		*path = fs.Arg(0)
+	}
+
+	cfg, err := metis.LoadConfig(*path, *token, "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *path, err)
+		return 1
+	}
+	var names []string
+	if *destinations != "" {
+		names = strings.Split(*destinations, ",")
+	}
+	results, err := metis.SendTestAlert(cfg, names, *resolve)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "no enabled destination to send to")
+		return 1
+	}
+
+	sent := make([]string, 0, len(results))
+	for name := range results {
+		sent = append(sent, name)
+	}
+	sort.Strings(sent)
+	code := 0
+	for _, name := range sent {
+		if results[name] != nil {
+			fmt.Printf("%s: %v\n", name, results[name])
+			code = 1
+			continue
+		}
+		fmt.Printf("%s: ok\n", name)
+	}
+	return code
+}
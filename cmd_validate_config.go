@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/b-harvest/metisian/metis"
+	"os"
+)
+
+const validateConfigUsage = `usage: metisian validate-config [flags] [config]
+
+checks a configuration file, or a remote (http or https) configuration, and reports every problem found. it exits
+with 1 when there is any, so that configuration changes can be checked before they are deployed.
+`
+
+// validateConfigCmd reports every problem of a configuration at once, instead of the first one metisian would
+// refuse to start with.
+func validateConfigCmd(args []string) int {
+	fs := flag.NewFlagSet("validate-config", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, validateConfigUsage)
+		fs.PrintDefaults()
+	}
+	path := fs.String("config", envOr("CONFIG_FILE_PATH", "config.toml"), "configuration toml file path or url, also set through env CONFIG_FILE_PATH")
+	token := fs.String("config-token", os.Getenv("CONFIG_TOKEN"), "bearer token of a remote configuration, also set through env CONFIG_TOKEN")
+	_ = fs.Parse(args)
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	if fs.NArg() == 1 {
+		*path = fs.Arg(0)
+	}
+
+	problems, err := metis.CheckConfig(*path, *token)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *path, err)
+		return 1
+	}
+	for _, problem := range problems {
+		fmt.Printf("%s: %s\n", *path, problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "%d problems found\n", len(problems))
+		return 1
+	}
+	fmt.Printf("%s: ok\n", *path)
+	return 0
+}
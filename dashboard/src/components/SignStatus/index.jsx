@@ -201,6 +201,7 @@ function drawSeries(multiStates) {
 const SignStatus = () => {
     const { statusData, setStatusData } = useSeqStatus(); 
   const [logs, setLogs] = useState([]);
+  const [nodes, setNodes] = useState([]);
   const logRef = useRef(null);
   const HOST = import.meta.env.VITE_API_HOST? import.meta.env.VITE_API_HOST: "localhost:8888/"
   const PROTOCOL = "https://"
@@ -215,6 +216,28 @@ const SignStatus = () => {
     legend();
   }, []);
 
+  useEffect(() => {
+    loadNodes();
+    const timer = setInterval(loadNodes, 15000);
+    return () => clearInterval(timer);
+  }, []);
+
+  const loadNodes = async () => {
+    try {
+      const nodesResponse = await fetch(PROTOCOL + HOST + "nodes", {
+        method: 'GET',
+        mode: 'cors',
+        cache: 'no-cache',
+        credentials: 'same-origin',
+        redirect: 'error',
+        referrerPolicy: 'no-referrer'
+      });
+      setNodes(await nodesResponse.json());
+    } catch (error) {
+      console.error(error);
+    }
+  };
+
   const loadState = async () => {
     try {
       const enableLogsResponse = await fetch(PROTOCOL + HOST + "logsenabled", {
@@ -267,11 +290,14 @@ const SignStatus = () => {
     // Clear existing rows
     table.innerHTML = '';
 
+    // with several networks, sequencers are shown with their chain id.
+    const multiChain = new Set(status.Status.map(item => item.chain_id)).size > 1;
+
     status.Status.forEach((item, i) => {
         let alerts = "&nbsp;";
         if (item.active_alerts > 0 || item.last_error !== "") {
             let alerts = "&nbsp;";
-            let modalId = encodeURIComponent(item.name);
+            let modalId = encodeURIComponent(item.chain_id + "-" + item.name);
             let lastError = encodeURIComponent(item.last_error);
 
             if (item.active_alerts > 0 || item.last_error !== "") {
@@ -304,7 +330,7 @@ const SignStatus = () => {
       row.insertCell(0).innerHTML = `<div>${alerts}</div>`;
       row.insertCell(1).innerHTML = item.name === "not connected"
       ? `<div class="uk-text-warning">${escape(item.name)}</div>`
-      : `<div class='uk-text-truncate'>${escape(item.name.substring(0, 24))}</div>`;
+      : `<div class='uk-text-truncate'>${multiChain ? `<span class="uk-text-muted">${escape(item.chain_id)}</span> ` : ""}${escape(item.name.substring(0, 24))}</div>`;
       row.insertCell(2).innerHTML = `<div>${escape(item.address)}</div>`;
       row.insertCell(3).innerHTML = `<div>${formattedPercentage}</div>`;
     });
@@ -370,6 +396,35 @@ const SignStatus = () => {
         </table>
       </div>
 
+      <div className="uk-padding-small uk-text-small uk-background-default uk-overflow-auto" id="nodesDiv">
+        <table className="uk-table uk-table-small uk-table-justify uk-padding-remove">
+          <thead>
+            <tr>
+              <th></th>
+              <th className="uk-text-center">RPC Node</th>
+              <th className="uk-text-center">Score</th>
+              <th className="uk-text-center">Height (lag)</th>
+              <th className="uk-text-center">Latency</th>
+              <th className="uk-text-center">Error rate</th>
+            </tr>
+          </thead>
+          <tbody>
+            {nodes.map(node => (
+              <tr key={node.rpc_url} title={node.last_error}>
+                <td>{node.active ? "▶" : ""}</td>
+                <td className="uk-text-truncate">{node.rpc_url}</td>
+                <td className="uk-text-center">
+                  {node.forked ? "forked" : node.down ? (node.catching_up ? "catching up" : "down") : node.score.toFixed(0)}
+                </td>
+                <td className="uk-text-center">{node.height} ({node.lag})</td>
+                <td className="uk-text-center">{node.latency_ms} ms</td>
+                <td className="uk-text-center">{(node.error_rate * 100).toFixed(0)}%</td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </div>
+
       <div className="uk-padding-large uk-padding-remove-top" id="logContainer">
       <pre
         ref={logRef}
@@ -42,24 +42,80 @@ func enqueue(event func()) {
 	eventQueue <- event
 }
 
-func Info(msg string) {
+// Fields are structured context of a log event, such as the sequencer, its address, a height or an alert id.
+type Fields map[string]interface{}
+
+// Logger logs events with fields. The zero value logs without fields, like the package functions.
+type Logger struct {
+	fields Fields
+}
+
+var std = &Logger{}
+
+// With returns a logger adding fields to every event.
+func With(fields Fields) *Logger {
+	return std.With(fields)
+}
+
+// With returns a copy of the logger with more fields.
+func (l *Logger) With(fields Fields) *Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &Logger{fields: merged}
+}
+
+func (l *Logger) Info(msg string) {
 	event := func() {
-		log.Info().Msg(msg)
+		log.Info().Fields(map[string]interface{}(l.fields)).Msg(msg)
+		dispatch(zerolog.InfoLevel, msg, l.fields)
 	}
 	enqueue(event)
 }
 
-func Warn(msg string) {
+func (l *Logger) Warn(msg string) {
+	event := func() {
+		log.Warn().Fields(map[string]interface{}(l.fields)).Msg(msg)
+		dispatch(zerolog.WarnLevel, msg, l.fields)
+	}
+	enqueue(event)
+}
+
+func (l *Logger) Error(err error) {
+	_, _, f := util.Trace(2)
 	event := func() {
-		log.Warn().Msg(msg)
+		log.Error().Fields(map[string]interface{}(l.fields)).Err(err).Msg(f)
+		dispatch(zerolog.ErrorLevel, err.Error(), l.fields)
 	}
 	enqueue(event)
 }
 
+func (l *Logger) Debug(msg any) {
+	message := fmt.Sprint(msg)
+	event := func() {
+		log.Debug().Fields(map[string]interface{}(l.fields)).Msg(message)
+		dispatch(zerolog.DebugLevel, message, l.fields)
+	}
+	enqueue(event)
+}
+
+func Info(msg string) {
+	std.Info(msg)
+}
+
+func Warn(msg string) {
+	std.Warn(msg)
+}
+
 func Error(err error) {
 	_, _, f := util.Trace(2)
 	event := func() {
 		log.Error().Err(err).Msg(f)
+		dispatch(zerolog.ErrorLevel, err.Error(), nil)
 	}
 	enqueue(event)
 }
@@ -77,11 +133,7 @@ func Fatal(err error) {
 }
 
 func Debug(msg any) {
-	message := fmt.Sprint(msg)
-	event := func() {
-		log.Debug().Msg(message)
-	}
-	enqueue(event)
+	std.Debug(msg)
 }
 
 const (
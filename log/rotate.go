@@ -0,0 +1,130 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names the rotated files, it sorts in time order.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile appends to a file and moves it aside once it grows past maxSize, the rotated files are named after
+// the time of the rotation (metisian.log becomes metisian-2024-11-09T12-41-56.000.log). Rotated files older than
+// maxAge, and the oldest beyond maxBackups, are removed. A zero maxAge or maxBackups keeps them.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	mux  sync.Mutex
+	file *os.File
+	size int64
+}
+
+func newRotatingFile(path string, maxSizeMB, maxAgeDays, maxBackups int) (*rotatingFile, error) {
+	if maxSizeMB <= 0 {
+		return nil, fmt.Errorf("the log file size must be at least 1 MB, not %d", maxSizeMB)
+	}
+	r := &rotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+		maxBackups: maxBackups,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, err
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.prune()
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	//#nosec -- path is set on the command line
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			// keep logging to the current file rather than losing the event.
+			fmt.Fprintf(os.Stderr, "could not rotate %s: %v\n", r.path, err)
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	ext := filepath.Ext(r.path)
+	backup := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(r.path, ext), time.Now().UTC().Format(backupTimeFormat), ext)
+	if err := os.Rename(r.path, backup); err != nil {
+		// reopen the current file so that logging goes on.
+		_ = r.open()
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	go r.prune()
+	return nil
+}
+
+// prune removes the rotated files which are too old or too many.
+func (r *rotatingFile) prune() {
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+	entries, err := os.ReadDir(filepath.Dir(r.path))
+	if err != nil {
+		return
+	}
+
+	type backup struct {
+		name string
+		at   time.Time
+	}
+	backups := make([]backup, 0)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		at, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{name: name, at: at})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].at.After(backups[j].at) })
+
+	for i, b := range backups {
+		if (r.maxBackups > 0 && i >= r.maxBackups) || (r.maxAge > 0 && time.Since(b.at) > r.maxAge) {
+			_ = os.Remove(filepath.Join(filepath.Dir(r.path), b.name))
+		}
+	}
+}
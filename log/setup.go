@@ -0,0 +1,62 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Options configure the log output.
+type Options struct {
+	// Format is console, colorized for a terminal, or json, one object per line for log shippers.
+	Format string
+	// File also writes the logs to a file, rotated once it is MaxSizeMB large. Rotated files are removed after
+	// MaxAgeDays, and beyond the MaxBackups most recent ones, zero keeps them.
+	File       string
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+}
+
+// Setup replaces the default colorized console output on stderr.
+func Setup(o Options) error {
+	if o.Format != "console" && o.Format != "json" {
+		return fmt.Errorf("unknown log format %q, expected console or json", o.Format)
+	}
+	writers := []io.Writer{format(os.Stderr, o.Format, true)}
+	if o.File != "" {
+		f, err := newRotatingFile(o.File, o.MaxSizeMB, o.MaxAgeDays, o.MaxBackups)
+		if err != nil {
+			return err
+		}
+		writers = append(writers, format(f, o.Format, false))
+	}
+
+	done := make(chan struct{})
+	// the logger is only used from the logging goroutine.
+	enqueue(func() {
+		log.Logger = zerolog.New(zerolog.MultiLevelWriter(writers...)).With().Timestamp().Logger()
+		close(done)
+	})
+	<-done
+	return nil
+}
+
+func format(w io.Writer, format string, color bool) io.Writer {
+	if format == "json" {
+		return w
+	}
+	output := zerolog.ConsoleWriter{
+		Out:        w,
+		TimeFormat: time.RFC3339,
+		NoColor:    !color,
+	}
+	if color {
+		output.FormatLevel = logColorFormatter()
+	}
+	return output
+}
@@ -0,0 +1,89 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Event is a log message as passed to the sinks.
+type Event struct {
+	Time    time.Time
+	Level   zerolog.Level
+	Message string
+	Fields  Fields
+}
+
+// String returns the message followed by the fields, sorted by key.
+func (e Event) String() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+	keys := make([]string, 0, len(e.Fields))
+	for k := range e.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	b := strings.Builder{}
+	b.WriteString(e.Message)
+	for _, k := range keys {
+		b.WriteString(fmt.Sprintf(" %s=%v", k, e.Fields[k]))
+	}
+	return b.String()
+}
+
+// Sink receives the log events at or above its minimum level, in addition to the logger output. Sinks are called
+// one at a time from the logging goroutine, so they must not block.
+type Sink interface {
+	Write(e Event)
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(e Event)
+
+func (f SinkFunc) Write(e Event) {
+	f(e)
+}
+
+type sinkEntry struct {
+	sink Sink
+	min  zerolog.Level
+}
+
+var (
+	sinks   = make(map[int]sinkEntry)
+	sinkId  int
+	sinkMux sync.RWMutex
+)
+
+// AddSink registers a sink for the events at or above min, and returns a function removing it.
+func AddSink(s Sink, min zerolog.Level) (remove func()) {
+	sinkMux.Lock()
+	defer sinkMux.Unlock()
+	sinkId++
+	id := sinkId
+	sinks[id] = sinkEntry{sink: s, min: min}
+	return func() {
+		sinkMux.Lock()
+		defer sinkMux.Unlock()
+		delete(sinks, id)
+	}
+}
+
+func dispatch(level zerolog.Level, msg string, fields Fields) {
+	sinkMux.RLock()
+	defer sinkMux.RUnlock()
+	if len(sinks) == 0 {
+		return
+	}
+	e := Event{Time: time.Now(), Level: level, Message: msg, Fields: fields}
+	for _, entry := range sinks {
+		if level >= entry.min {
+			entry.sink.Write(e)
+		}
+	}
+}
@@ -7,19 +7,33 @@ import (
 	"github.com/b-harvest/metisian/metis"
 	"github.com/rs/zerolog"
 	"os"
+	"time"
 )
 
 var (
 	cfg *metis.Config
+
+	// the configuration is loaded again from configFilePath on reloads.
+	configFilePath  string
+	configFileToken string
+	configPoll      time.Duration
 )
 
-func init() {
+// commands are the subcommands of metisian, without one the monitor is started.
+var commands = map[string]func(args []string) int{
+	"silence":         silenceCmd,
+	"dead-letter":     deadLetterCmd,
+	"validate-config": validateConfigCmd,
+	"print-config":    printConfigCmd,
+	"test-alert":      testAlertCmd,
+}
+
+func setup() {
 
 	var (
-		configFilePath  string
-		configFileToken string
-		logLevel        string
-		stateFile       string
+		logLevel   string
+		logOptions log.Options
+		stateFile  string
 
 		EnvConfigFilePath  = "CONFIG_FILE_PATH"
 		EnvConfigFileToken = "CONFIG_TOKEN"
@@ -34,8 +48,16 @@ func init() {
 		fmt.Sprintf("If you set this, it'll be use as Authorization Header with `Bearer $CONFIG_TOKEN`.\n"+
 			"you could also set this value with env %s.\n"+
 			"If both set, env value will be used.", EnvConfigFileToken))
+	flag.DurationVar(&configPoll, "config-poll", time.Minute,
+		"how often a remote (http or https) configuration is fetched again to apply its changes, 0 disables it.\n"+
+			"a local file is reloaded when it changes, both are also reloaded on SIGHUP.")
 	flag.StringVar(&stateFile, "state", ".metisian-state.json", "file for storing state between restarts")
 	flag.StringVar(&logLevel, "log-level", "info", "log level you would show. (debug, info, warn, error...)")
+	flag.StringVar(&logOptions.Format, "log-format", "console", "log format, console (colorized) or json (one object per line)")
+	flag.StringVar(&logOptions.File, "log-file", "", "also write the logs to this file, rotated by size")
+	flag.IntVar(&logOptions.MaxSizeMB, "log-max-size", 100, "size in MB at which the log file is rotated")
+	flag.IntVar(&logOptions.MaxAgeDays, "log-max-age", 30, "days rotated log files are kept, 0 keeps them")
+	flag.IntVar(&logOptions.MaxBackups, "log-max-backups", 10, "number of rotated log files kept, 0 keeps them all")
 
 	flag.Parse()
 
@@ -51,6 +73,9 @@ func init() {
 		panic(err)
 	}
 	zerolog.SetGlobalLevel(l)
+	if err = log.Setup(logOptions); err != nil {
+		panic(err)
+	}
 
 	cfg, err = metis.LoadConfig(configFilePath, configFileToken, stateFile)
 	if err != nil {
@@ -59,25 +84,35 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
+
+	setup()
 
-	client, err := metis.NewClient(cfg)
+	supervisor, err := metis.NewSupervisor(cfg)
 	if err != nil {
 		panic(err)
 	}
 
-	defer client.Cancel()
+	defer supervisor.Cancel()
 
-	go client.Run()
+	go supervisor.Run()
+	go supervisor.WatchConfig(supervisor.Ctx, configFilePath, configFileToken, configPoll)
 
 	var seqAddrsMsg string
-	for n, seq := range client.GetSequencers() {
-		seqAddrsMsg = fmt.Sprintf("%s✅ [%15.15s] %s\n", seqAddrsMsg, n, seq.Address)
+	for _, client := range supervisor.Clients {
+		if len(supervisor.Clients) > 1 {
+			seqAddrsMsg = fmt.Sprintf("%s🌐 %s\n", seqAddrsMsg, client.ChainId)
+		}
+		for n, seq := range client.GetSequencers() {
+			seqAddrsMsg = fmt.Sprintf("%s✅ [%15.15s] %s\n", seqAddrsMsg, n, seq.Address)
+		}
 	}
 	log.Info(fmt.Sprintf("Starting monitor metis sequencers...\n%s", seqAddrsMsg))
 
-	saved := make(chan interface{})
-	client.SaveOnExit(cfg.StateFile, saved)
-	<-client.Ctx.Done()
-	<-saved
+	supervisor.SaveOnExit()
 
 }
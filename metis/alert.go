@@ -1,57 +1,53 @@
 package metis
 
 import (
-	"bytes"
-	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/PagerDuty/go-pagerduty"
 	log "github.com/b-harvest/metisian/log"
-	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/r3labs/diff"
-	"net/http"
 	"strings"
 	"sync"
 	"time"
 )
 
 type alertMsg struct {
-	pd   bool
-	disc bool
-	tg   bool
-	slk  bool
-	lark bool
-
 	severity  string
 	resolved  bool
 	sequencer string
+	address   string
 	message   string
 	uniqueId  string
-	key       string
-
-	tgChannel  string
-	tgKey      string
-	tgMentions string
-
-	discHook     string
-	discMentions string
-
-	slkHook     string
-	slkMentions string
-
-	larkHook string
+	chainId   string
+
+	// destinations limits delivery to these notifiers, every enabled notifier is used when nil.
+	destinations []string
+	// renotify sends the alert again to destinations which already received it.
+	renotify bool
+	// deliveryId is the id of the queued delivery sending the alert, it is the same for every attempt.
+	deliveryId string
+
+	// dest is the sequencer's destination configuration at the time the alert was raised, every Notifier reads
+	// its own section from it.
+	dest Destinations
 }
 
-type notifyDest uint8
+// logger adds the sequencer, the alert id and the severity to log events.
+func (msg *alertMsg) logger() *log.Logger {
+	return log.With(log.Fields{"sequencer": msg.sequencer, "alert_id": msg.uniqueId, "severity": msg.severity})
+}
 
-const (
-	pd notifyDest = iota
-	tg
-	di
-	slk
-	lark
-)
+// firedAlarm is an unresolved alarm as it fired, the notifiers keeping track of their alarms need its severity and
+// id again after a restart.
+type firedAlarm struct {
+	Sequencer string    `json:"sequencer"`
+	Message   string    `json:"message"`
+	Severity  string    `json:"severity"`
+	UniqueId  string    `json:"unique_id"`
+	Since     time.Time `json:"since"`
+
+	// confirmed is false for an alarm restored from the state file until it fires again.
+	confirmed bool
+}
 
 func (a *alarmCache) clearNoBlocks(seqeuncer string) {
 	if a.AllAlarms == nil || a.AllAlarms[seqeuncer] == nil {
@@ -62,6 +58,7 @@ func (a *alarmCache) clearNoBlocks(seqeuncer string) {
 	for clearAlarm := range a.AllAlarms[seqeuncer] {
 		if strings.HasPrefix(clearAlarm, "stalled: have not seen a new block on") {
 			delete(a.AllAlarms[seqeuncer], clearAlarm)
+			delete(a.Fired, seqeuncer+clearAlarm)
 		}
 	}
 }
@@ -81,55 +78,52 @@ func (a *alarmCache) clearAll(chain string) {
 	}
 	a.notifyMux.Lock()
 	defer a.notifyMux.Unlock()
+	for message := range a.AllAlarms[chain] {
+		delete(a.Fired, chain+message)
+	}
 	a.AllAlarms[chain] = make(map[string]time.Time)
 }
 
-// alarms is used to prevent double notifications.
-var alarms = &alarmCache{
-	SentPdAlarms:   make(map[string]time.Time),
-	SentTgAlarms:   make(map[string]time.Time),
-	SentDiAlarms:   make(map[string]time.Time),
-	SentSlkAlarms:  make(map[string]time.Time),
-	SentLarkAlarms: make(map[string]time.Time),
-	AllAlarms:      make(map[string]map[string]time.Time),
-	flappingAlarms: make(map[string]map[string]time.Time),
-	notifyMux:      sync.RWMutex{},
+// sentAlarms returns the alarms delivered to a destination, the caller must hold notifyMux.
+func (a *alarmCache) sentAlarms(service string) map[string]time.Time {
+	if a.Sent[service] == nil {
+		a.Sent[service] = make(map[string]time.Time)
+	}
+	return a.Sent[service]
+}
+
+// newAlarmCache returns an empty cache, every network keeps its own to prevent double notifications.
+func newAlarmCache() *alarmCache {
+	return &alarmCache{
+		Sent:           make(map[string]map[string]time.Time),
+		AllAlarms:      make(map[string]map[string]time.Time),
+		Fired:          make(map[string]*firedAlarm),
+		Escalations:    make(map[string]*escalation),
+		flappingAlarms: make(map[string]map[string]time.Time),
+		silenced:       make(map[string]*alertMsg),
+		notifyMux:      sync.RWMutex{},
+	}
 }
 
-func shouldNotify(msg *alertMsg, dest notifyDest) bool {
-	alarms.notifyMux.Lock()
-	defer alarms.notifyMux.Unlock()
-	var whichMap map[string]time.Time
-	var service string
-	if alarms.AllAlarms[msg.sequencer] == nil {
-		alarms.AllAlarms[msg.sequencer] = make(map[string]time.Time)
-	}
-	switch dest {
-	case pd:
-		whichMap = alarms.SentPdAlarms
-		service = "PagerDuty"
-	case tg:
-		whichMap = alarms.SentTgAlarms
-		service = "Telegram"
-	case di:
-		whichMap = alarms.SentDiAlarms
-		service = "Discord"
-	case slk:
-		whichMap = alarms.SentSlkAlarms
-		service = "Slack"
-	case lark:
-		whichMap = alarms.SentLarkAlarms
-		service = "Lark"
+func (a *alarmCache) shouldNotify(msg *alertMsg, dest Notifier) bool {
+	a.notifyMux.Lock()
+	defer a.notifyMux.Unlock()
+	service := dest.Name()
+	whichMap := a.sentAlarms(service)
+	if a.AllAlarms[msg.sequencer] == nil {
+		a.AllAlarms[msg.sequencer] = make(map[string]time.Time)
 	}
 
 	switch {
+	case !whichMap[msg.sequencer+msg.message].IsZero() && !msg.resolved && msg.renotify:
+		msg.logger().With(log.Fields{"destination": service}).Info("🔁 re-notifying alarm: " + msg.message)
+		return true
 	case !whichMap[msg.sequencer+msg.message].IsZero() && !msg.resolved:
 		// already sent this alert
 		return false
 	case !whichMap[msg.sequencer+msg.message].IsZero() && msg.resolved:
-		// alarm is cleared
-		delete(whichMap, msg.sequencer+msg.message)
-		log.Info(fmt.Sprintf("💜 Resolved     alarm on %20s (%s) - notifying %s", msg.sequencer, msg.message, service))
+		// alarm is cleared, it is forgotten once the resolution is delivered
+		msg.logger().With(log.Fields{"destination": service}).Info("💜 resolved alarm: " + msg.message)
 		return true
 	case msg.resolved:
 		// it looks like we got a duplicate resolution or suppressed it. Note it and move on:
@@ -138,335 +132,190 @@ func shouldNotify(msg *alertMsg, dest notifyDest) bool {
 	}
 
 	// check if the alarm is flapping, if we sent the same alert in the last five minutes, show a warning but don't alert
-	if alarms.flappingAlarms[msg.sequencer] == nil {
-		alarms.flappingAlarms[msg.sequencer] = make(map[string]time.Time)
+	if a.flappingAlarms[msg.sequencer] == nil {
+		a.flappingAlarms[msg.sequencer] = make(map[string]time.Time)
 	}
 
-	// for pagerduty we perform some basic flap detection
-	if dest == pd && msg.pd && alarms.flappingAlarms[msg.sequencer][msg.message].After(time.Now().Add(-5*time.Minute)) {
-		log.ErrorDynamicArgs("🛑 flapping detected - suppressing pagerduty notification:", msg.sequencer, msg.message)
-		return false
-	} else if dest == pd && msg.pd {
-		alarms.flappingAlarms[msg.sequencer][msg.message] = time.Now()
+	// destinations such as pagerduty get some basic flap detection
+	if fs, ok := dest.(flapSuppressor); ok && fs.suppressFlapping() {
+		if a.flappingAlarms[msg.sequencer][msg.message].After(time.Now().Add(-5 * time.Minute)) {
+			log.ErrorDynamicArgs(fmt.Sprintf("🛑 flapping detected - suppressing %s notification:", service), msg.sequencer, msg.message)
+			return false
+		}
+		a.flappingAlarms[msg.sequencer][msg.message] = time.Now()
 	}
 
-	log.Info(fmt.Sprintf("new alarm on %20s (%s) - notifying %s", msg.sequencer, msg.message, service))
-	whichMap[msg.sequencer+msg.message] = time.Now()
+	msg.logger().With(log.Fields{"destination": service}).Info("new alarm: " + msg.message)
 	return true
 }
 
-func notifySlack(msg *alertMsg) (err error) {
-	if !msg.slk {
-		return
-	}
-	if !shouldNotify(msg, slk) {
-		return nil
-	}
-	data, err := json.Marshal(buildSlackMessage(msg))
-	if err != nil {
-		return
-	}
-
-	req, err := http.NewRequest("POST", msg.slkHook, bytes.NewBuffer(data))
-	if err != nil {
-		return
-	}
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
+// markDelivered records that a destination received an alarm, or its resolution. Until then the alarm isn't
+// considered sent to the destination.
+func (a *alarmCache) markDelivered(service, key string, resolved bool) {
+	a.notifyMux.Lock()
+	defer a.notifyMux.Unlock()
+	if resolved {
+		delete(a.sentAlarms(service), key)
 		return
 	}
-	_ = resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("could not notify slack for %s got %d response", msg.sequencer, resp.StatusCode)
-	}
-
-	return
+	a.sentAlarms(service)[key] = time.Now()
 }
 
-type SlackMessage struct {
-	Text        string       `json:"text"`
-	Attachments []Attachment `json:"attachments"`
+func (a *alarmCache) wasSent(service, key string) bool {
+	a.notifyMux.Lock()
+	defer a.notifyMux.Unlock()
+	return !a.sentAlarms(service)[key].IsZero()
 }
 
-type Attachment struct {
-	Text      string `json:"text"`
-	Color     string `json:"color"`
-	Title     string `json:"title"`
-	TitleLink string `json:"title_link"`
+func (a *alarmCache) isActive(seqName, message string) bool {
+	a.notifyMux.RLock()
+	defer a.notifyMux.RUnlock()
+	return !a.AllAlarms[seqName][message].IsZero()
 }
 
-func buildSlackMessage(msg *alertMsg) *SlackMessage {
-	prefix := ""
-	color := "danger"
-	if msg.resolved {
-		msg.message = "OK: " + msg.message
-		prefix = "💜 Resolved: "
-		color = "good"
-	}
-	return &SlackMessage{
-		Text: msg.message,
-		Attachments: []Attachment{
-			{
-				Title: fmt.Sprintf("Metisian %s %s %s", prefix, msg.sequencer, msg.slkMentions),
-				Color: color,
-			},
-		},
+func (a *alarmCache) getAlarms(chain string) string {
+	a.notifyMux.RLock()
+	defer a.notifyMux.RUnlock()
+	// don't show this info if the logs are disabled on the dashboard, potentially sensitive info could be leaked.
+	result := ""
+	for k := range a.AllAlarms[chain] {
+		result += "🚨 " + k + "\n"
 	}
+	return result
 }
 
-func notifyLark(msg *alertMsg) (err error) {
-	if !msg.lark {
-		return
-	}
-	if !shouldNotify(msg, lark) {
-		return nil
-	}
-	data, err := json.Marshal(buildLarkMessage(msg))
-	if err != nil {
-		return
+// alert creates a universal alert and pushes it to the alertChan to be delivered to appropriate services
+func (c *MetisianClient) alert(seqName, message, severity string, resolved, notSend bool, id *string) {
+	uniq := seqName
+	if id != nil {
+		uniq = *id
 	}
 
-	req, err := http.NewRequest("POST", msg.larkHook, bytes.NewBuffer(data))
-	if err != nil {
-		return
+	if c.sequencers()[seqName] == nil {
+		msg := fmt.Sprintf("No sequencer found with Name: %s", seqName)
+		log.Error(errors.New(msg))
+		message = fmt.Sprintf("%s\ncontent: \n%s", msg, message)
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return
+	silenced := false
+	if !notSend && !resolved && c.isSilenced(seqName, uniq) {
+		log.With(log.Fields{"sequencer": seqName, "alert_id": uniq}).Info("🔕 silenced alarm: " + message)
+		notSend, silenced = true, true
 	}
-	_ = resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("could not notify slack for %s got %d response", msg.sequencer, resp.StatusCode)
+	a := c.newAlertMsg(seqName, message, severity, resolved, uniq)
+	if c.alarms.holdSilenced(seqName+message, a, silenced) && resolved {
+		log.With(log.Fields{"sequencer": seqName, "alert_id": uniq}).Info("🔕 silenced alarm resolved before it was sent: " + message)
+		notSend = true
 	}
 
-	return
-}
-
-// lark - sendMessage simple
-//
-//	{
-//		"msg_type": "text",
-//		"content": {
-//			"text": "'"$MESSAGE"'"
-//		}
-//	}
-type LarkMessage struct {
-	MsgType string      `json:"msg_type"`
-	Content LarkContent `json:"content"`
-}
-
-type LarkContent struct {
-	Text string `json:"text"`
-}
-
-func buildLarkMessage(msg *alertMsg) *LarkMessage {
-	prefix := ""
-	if msg.resolved {
-		msg.message = "OK: " + msg.message
-		prefix = "💜 Resolved: "
+	c.recordAlert(seqName, uniq, message, severity, resolved, !notSend)
+	if resolved {
+		// an escalated alarm is only resolved where it has been delivered.
+		a.destinations = c.alarms.stopEscalation(seqName + message)
+	} else if policy := c.escalationPolicy(seqName, severity); policy != nil && !notSend {
+		a.destinations = policy.Destinations
+		c.alarms.startEscalation(seqName+message, policy, a)
 	}
-	return &LarkMessage{
-		MsgType: "text",
-		Content: LarkContent{
-			Text: fmt.Sprintf("Metisian %s %s\n%s", prefix, msg.sequencer, msg.message),
-		},
-	}
-}
-
-func notifyDiscord(msg *alertMsg) (err error) {
-	if !msg.disc {
-		return nil
-	}
-	if !shouldNotify(msg, di) {
-		return nil
-	}
-	discPost := buildDiscordMessage(msg)
-	client := &http.Client{}
-	data, err := json.MarshalIndent(discPost, "", "  ")
-	if err != nil {
-		log.ErrorDynamicArgs("⚠️ Could not notify discord!", err)
-		return err
-	}
-
-	req, err := http.NewRequest("POST", msg.discHook, bytes.NewBuffer(data))
-	if err != nil {
-		log.ErrorDynamicArgs("⚠️ Could not notify discord!", err)
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
-	if err != nil {
-		log.ErrorDynamicArgs("⚠️ Could not notify discord!", err)
-		return err
+	if !notSend {
+		c.alertChan <- a
 	}
-	_ = resp.Body.Close()
-
-	if resp.StatusCode != 204 {
-		log.Info(fmt.Sprintf("%v", resp))
-		log.ErrorDynamicArgs("⚠️ Could not notify discord! Returned", resp.StatusCode)
-		return err
+	c.alarms.notifyMux.Lock()
+	defer c.alarms.notifyMux.Unlock()
+	if c.alarms.AllAlarms[seqName] == nil {
+		c.alarms.AllAlarms[seqName] = make(map[string]time.Time)
 	}
-	return nil
-}
-
-type DiscordMessage struct {
-	Username  string         `json:"username,omitempty"`
-	AvatarUrl string         `json:"avatar_url,omitempty"`
-	Content   string         `json:"content"`
-	Embeds    []DiscordEmbed `json:"embeds,omitempty"`
-}
-
-type DiscordEmbed struct {
-	Title       string `json:"title,omitempty"`
-	Url         string `json:"url,omitempty"`
-	Description string `json:"description"`
-	Color       uint   `json:"color"`
-}
-
-func buildDiscordMessage(msg *alertMsg) *DiscordMessage {
-	prefix := ""
-	if msg.resolved {
-		prefix = "💜 Resolved: "
+	if resolved {
+		delete(c.alarms.AllAlarms[seqName], message)
+		delete(c.alarms.Fired, seqName+message)
+		return
 	}
-	return &DiscordMessage{
-		Username: "Metisian",
-		Content:  prefix + msg.sequencer,
-		Embeds: []DiscordEmbed{{
-			Description: msg.message,
-		}},
+	c.alarms.AllAlarms[seqName][message] = time.Now()
+	if f := c.alarms.Fired[seqName+message]; f != nil {
+		f.confirmed = true
+	} else {
+		c.alarms.Fired[seqName+message] = &firedAlarm{Sequencer: seqName, Message: message, Severity: severity, UniqueId: uniq, Since: time.Now(), confirmed: true}
 	}
 }
 
-func notifyTg(msg *alertMsg) (err error) {
-	if !msg.tg {
-		return nil
-	}
-	if !shouldNotify(msg, tg) {
-		return nil
-	}
-	bot, err := tgbotapi.NewBotAPI(msg.tgKey)
-	if err != nil {
-		log.ErrorDynamicArgs("notify telegram:", err)
+// restoreAlarms loads the unresolved alarms of a previous run, so that they are neither sent twice nor left
+// unresolved, along with their escalations. Notifiers keeping track of their alarms are given the ones they received.
+func (c *MetisianClient) restoreAlarms(saved *alarmCache) {
+	if saved == nil {
 		return
 	}
-
-	prefix := ""
-	if msg.resolved {
-		prefix = "💜 Resolved: "
+	fired := saved.Fired
+	if fired == nil {
+		// state files written before every alarm was saved only have the escalated ones.
+		fired = make(map[string]*firedAlarm)
+		for key, e := range saved.Escalations {
+			fired[key] = &firedAlarm{Sequencer: e.Sequencer, Message: e.Message, Severity: e.Severity, UniqueId: e.UniqueId, Since: e.Since}
+		}
 	}
 
-	mc := tgbotapi.NewMessageToChannel(msg.tgChannel, fmt.Sprintf("%s: %s - %s", msg.sequencer, prefix, msg.message))
-	_, err = bot.Send(mc)
-	if err != nil {
-		log.ErrorDynamicArgs("telegram send:", err)
+	policies := c.thresholds().escalations
+	delivered := make(map[string][]firedAlarm)
+	c.alarms.notifyMux.Lock()
+	for key, e := range saved.Escalations {
+		if fired[key] == nil || escalationPolicyByName(policies, e.Policy) == nil {
+			continue
+		}
+		e.confirmed = false
+		c.alarms.Escalations[key] = e
+	}
+	for key, f := range fired {
+		f.confirmed = false
+		c.alarms.Fired[key] = f
+		for service, sent := range saved.Sent {
+			if !sent[key].IsZero() {
+				c.alarms.sentAlarms(service)[key] = sent[key]
+				delivered[service] = append(delivered[service], *f)
+			}
+		}
+		if c.alarms.AllAlarms[f.Sequencer] == nil {
+			c.alarms.AllAlarms[f.Sequencer] = make(map[string]time.Time)
+		}
+		c.alarms.AllAlarms[f.Sequencer][f.Message] = f.Since
 	}
-	return err
-}
+	c.alarms.notifyMux.Unlock()
 
-func notifyPagerduty(msg *alertMsg) (err error) {
-	if !msg.pd {
-		return nil
-	}
-	if !shouldNotify(msg, pd) {
-		return nil
-	}
-	// key from the example, don't spam their api
-	if msg.key == "aaaaaaaaaaaabbbbbbbbbbbbbcccccccccccc" {
-		log.Error(errors.New("invalid pagerduty key"))
-		return
+	for _, notifier := range registeredNotifiers() {
+		if r, ok := notifier.(alarmRestorer); ok {
+			for _, f := range delivered[notifier.Name()] {
+				r.restoreAlarm(c.newAlertMsg(f.Sequencer, f.Message, f.Severity, false, f.UniqueId), f.Since)
+			}
+		}
 	}
-	action := "trigger"
-	if msg.resolved {
-		action = "resolve"
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	_, err = pagerduty.ManageEventWithContext(ctx, pagerduty.V2Event{
-		RoutingKey: msg.key,
-		Action:     action,
-		DedupKey:   msg.uniqueId,
-		Payload: &pagerduty.V2Payload{
-			Summary:  msg.message,
-			Source:   msg.uniqueId,
-			Severity: msg.severity,
-		},
-	})
-	return
 }
 
-func getAlarms(chain string) string {
-	alarms.notifyMux.RLock()
-	defer alarms.notifyMux.RUnlock()
-	// don't show this info if the logs are disabled on the dashboard, potentially sensitive info could be leaked.
-	result := ""
-	for k := range alarms.AllAlarms[chain] {
-		result += "🚨 " + k + "\n"
+// destinationsFor returns the destinations of a sequencer, or of Metisian itself if the sequencer isn't known.
+func (c *MetisianClient) destinationsFor(seqName string) Destinations {
+	sequencers := c.sequencers()
+	if seq := sequencers[seqName]; seq != nil {
+		return seq.Alerts.Destinations
 	}
-	return result
+	return sequencers[MetisianName].Alerts.Destinations
 }
 
-// alert creates a universal alert and pushes it to the alertChan to be delivered to appropriate services
-func (c *MetisianClient) alert(seqName, message, severity string, resolved, notSend bool, id *string) {
-	uniq := seqName
-	if id != nil {
-		uniq = *id
-	}
-
-	var seqAlert AlertConfig
-	if c.Sequencers[seqName] == nil {
-		msg := fmt.Sprintf("No sequencer found with Name: %s", seqName)
-		log.Error(errors.New(msg))
-		seqAlert = c.Sequencers[MetisianName].Alerts
-
-		message = fmt.Sprintf("%s\ncontent: \n%s", msg, message)
-	} else {
-		seqAlert = c.Sequencers[seqName].Alerts
+// newAlertMsg creates the message for an alert, using the destinations of the sequencer or, if the sequencer isn't
+// known, of Metisian itself.
+func (c *MetisianClient) newAlertMsg(seqName, message, severity string, resolved bool, uniq string) *alertMsg {
+	sequencers := c.sequencers()
+	seq := sequencers[seqName]
+	if seq == nil {
+		seq = sequencers[MetisianName]
+	}
+	return &alertMsg{
+		severity:  severity,
+		resolved:  resolved,
+		sequencer: seqName,
+		address:   seq.Address,
+		message:   message,
+		uniqueId:  uniq,
+		chainId:   c.ChainId,
+		dest:      seq.Alerts.Destinations,
 	}
-
-	if !notSend {
-		c.seqMux.RLock()
-		a := &alertMsg{
-			pd:           seqAlert.Pagerduty.Enabled,
-			disc:         seqAlert.Discord.Enabled,
-			tg:           seqAlert.Telegram.Enabled,
-			slk:          seqAlert.Slack.Enabled,
-			lark:         seqAlert.Lark.Enabled,
-			severity:     severity,
-			resolved:     resolved,
-			sequencer:    seqName,
-			message:      message,
-			uniqueId:     uniq,
-			key:          seqAlert.Pagerduty.ApiKey,
-			tgChannel:    seqAlert.Telegram.Channel,
-			tgKey:        seqAlert.Telegram.ApiKey,
-			tgMentions:   strings.Join(seqAlert.Telegram.Mentions, " "),
-			discHook:     seqAlert.Discord.Webhook,
-			discMentions: strings.Join(seqAlert.Discord.Mentions, " "),
-			slkHook:      seqAlert.Slack.Webhook,
-			larkHook:     seqAlert.Lark.Webhook,
-		}
-		c.alertChan <- a
-		c.seqMux.RUnlock()
-	}
-	alarms.notifyMux.Lock()
-	defer alarms.notifyMux.Unlock()
-	if alarms.AllAlarms[seqName] == nil {
-		alarms.AllAlarms[seqName] = make(map[string]time.Time)
-	}
-	if resolved && !alarms.AllAlarms[seqName][message].IsZero() {
-		delete(alarms.AllAlarms[seqName], message)
-		return
-	} else if resolved {
-		return
-	}
-	alarms.AllAlarms[seqName][message] = time.Now()
 }
 
 // watch handles monitoring for missed blocks, stalled sequencer, node downtime
@@ -484,7 +333,8 @@ func (c *MetisianClient) watch() {
 
 		if seq.valInfo == nil {
 			time.Sleep(time.Second)
-			if c.AlertIfNoServers && !noNodes && c.noNodes && noNodesSec >= 60*c.NodeDownMin {
+			t := c.thresholds()
+			if t.alertIfNoServers && !noNodes && c.noNodes && noNodesSec >= 60*t.nodeDownMin {
 				noNodes = true
 				c.alert(
 					MetisianName,
@@ -506,12 +356,13 @@ func (c *MetisianClient) watch() {
 
 	for {
 		time.Sleep(2 * time.Second)
+		t := c.thresholds()
 
 		// alert if we can't monitor
 		switch {
-		case c.AlertIfNoServers && !noNodes && c.noNodes:
+		case t.alertIfNoServers && !noNodes && c.noNodes:
 			noNodesSec += 2
-			if noNodesSec <= 30*c.NodeDownMin {
+			if noNodesSec <= 30*t.nodeDownMin {
 				if noNodesSec%20 == 0 {
 					log.ErrorDynamicArgs(fmt.Sprintf("no nodes available for %d seconds, deferring alarm", noNodesSec))
 				}
@@ -533,30 +384,30 @@ func (c *MetisianClient) watch() {
 		}
 
 		// stalled sequencer detection
-		if c.StalledAlerts && !c.lastBlockAlarm && !c.lastBlockTime.IsZero() &&
-			c.lastBlockTime.Before(time.Now().Add(time.Duration(-c.Stalled)*time.Minute)) {
+		if t.stalledAlerts && !c.lastBlockAlarm && !c.lastBlockTime.IsZero() &&
+			c.lastBlockTime.Before(time.Now().Add(time.Duration(-t.stalled)*time.Minute)) {
 
 			// sequencer is stalled send an alert!
 			c.lastBlockAlarm = true
 			c.alert(
 				MetisianName,
-				fmt.Sprintf("🚨 stalled: have not seen a new block in %d minutes", c.Stalled),
+				fmt.Sprintf("🚨 stalled: have not seen a new block in %d minutes", t.stalled),
 				"critical",
 				false,
 				false,
 				nil,
 			)
-		} else if c.StalledAlerts && c.lastBlockAlarm && c.lastBlockTime.IsZero() {
+		} else if t.stalledAlerts && c.lastBlockAlarm && c.lastBlockTime.IsZero() {
 			c.lastBlockAlarm = false
 			c.alert(
 				MetisianName,
-				fmt.Sprintf("🚨 stalled: have not seen a new block in %d minutes", c.Stalled),
+				fmt.Sprintf("🚨 stalled: have not seen a new block in %d minutes", t.stalled),
 				"info",
 				true,
 				false,
 				nil,
 			)
-			alarms.clearNoBlocks(MetisianName)
+			c.alarms.clearNoBlocks(MetisianName)
 		}
 
 		for _, seq := range c.GetSequencers() {
@@ -574,7 +425,7 @@ func (c *MetisianClient) watch() {
 					false,
 					&id,
 				)
-				seq.activeAlerts = alarms.getCount(seq.name)
+				seq.activeAlerts = c.alarms.getCount(seq.name)
 			} else if missedAlarm[seq.name] && int(seq.statConsecutiveMiss) < seq.Alerts.ConsecutiveMissed {
 				// clear the alert
 				missedAlarm[seq.name] = false
@@ -587,13 +438,13 @@ func (c *MetisianClient) watch() {
 					false,
 					&id,
 				)
-				seq.activeAlerts = alarms.getCount(seq.name)
+				seq.activeAlerts = c.alarms.getCount(seq.name)
 			}
 
 			// recommited sequencer alarms:
 			if seq.statNewSeqData == nil || len(seq.statNewSeqData.Epoches) == 0 {
 				if seq.statSeqData != nil {
-					log.Debug(fmt.Sprintf("no epochs detected for this sequencer %20s (%s)", seq.name, seq.Address))
+					seq.logger().Debug("no epochs detected for this sequencer")
 				} // skipping
 
 			} else {
@@ -617,7 +468,7 @@ func (c *MetisianClient) watch() {
 								false,
 								false,
 								&id)
-							seq.activeAlerts = alarms.getCount(seq.name)
+							seq.activeAlerts = c.alarms.getCount(seq.name)
 						} else if noSequencerSet[seq.name] && len(seq.statNewSeqData.Epoches) > 0 {
 							noSequencerSet[seq.name] = false
 							id := seq.Address + "sequencer-set"
@@ -628,7 +479,7 @@ func (c *MetisianClient) watch() {
 								true,
 								false,
 								&id)
-							seq.activeAlerts = alarms.getCount(seq.name)
+							seq.activeAlerts = c.alarms.getCount(seq.name)
 						}
 
 						if !noSequencerSet[seq.name] {
@@ -651,7 +502,7 @@ func (c *MetisianClient) watch() {
 									true,
 									true,
 									&id)
-								seq.activeAlerts = alarms.getCount(seq.name)
+								seq.activeAlerts = c.alarms.getCount(seq.name)
 							} else if seq.statSeqData.find(seq.statNewSeqData.Epoches[0].ID) == nil {
 								newTask := seq.statNewSeqData.Epoches[0]
 								msg := fmt.Sprintf("💎 sequencer has new mining task\t\tspanId: %4v, startBlock: %8s, endBlock: %8s, recommited: %t", newTask.ID, newTask.StartBlock, newTask.EndBlock, newTask.Recommited)
@@ -672,7 +523,7 @@ func (c *MetisianClient) watch() {
 										true,
 										false,
 										&id)
-									seq.activeAlerts = alarms.getCount(seq.name)
+									seq.activeAlerts = c.alarms.getCount(seq.name)
 								}
 							}
 
@@ -685,10 +536,11 @@ func (c *MetisianClient) watch() {
 		}
 
 		// node down alarms
-		for _, node := range c.Nodes {
+		for _, node := range c.nodes() {
+			st := node.health.snapshot()
 			// window percentage missed block alarms
-			if node.AlertIfDown && node.down && !node.wasDown && !node.downSince.IsZero() &&
-				time.Since(node.downSince) > time.Duration(c.NodeDownMin)*time.Minute {
+			if node.AlertIfDown && st.down && !st.wasDown && !st.downSince.IsZero() &&
+				time.Since(st.downSince) > time.Duration(t.nodeDownMin)*time.Minute {
 				// alert on dead node
 				if nodeAlarms[node.RpcURL] {
 					continue
@@ -696,19 +548,22 @@ func (c *MetisianClient) watch() {
 				nodeAlarms[node.RpcURL] = true // used to keep active alert count correct
 				c.alert(
 					MetisianName,
-					fmt.Sprintf("Severity: %s\nRPC node %s has been down for > %d minutes", c.NodeDownSeverity, node.RpcURL, c.NodeDownMin),
-					c.NodeDownSeverity,
+					fmt.Sprintf("Severity: %s\nRPC node %s has been down for > %d minutes", t.nodeDownSeverity, node.RpcURL, t.nodeDownMin),
+					t.nodeDownSeverity,
 					false,
 					false,
 					&node.RpcURL,
 				)
-			} else if node.AlertIfDown && !node.down && node.wasDown {
+			} else if node.AlertIfDown && !st.down && st.wasDown {
+				node.health.clearWasDown()
+				if !nodeAlarms[node.RpcURL] {
+					continue
+				}
 				// clear the alert
 				nodeAlarms[node.RpcURL] = false
-				node.wasDown = false
 				c.alert(
 					MetisianName,
-					fmt.Sprintf("Severity: %s\nRPC node %s has been down for > %d minutes on %s", c.NodeDownSeverity, node.RpcURL, c.NodeDownMin),
+					fmt.Sprintf("Severity: %s\nRPC node %s has been down for > %d minutes", t.nodeDownSeverity, node.RpcURL, t.nodeDownMin),
 					"info",
 					true,
 					false,
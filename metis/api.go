@@ -0,0 +1,400 @@
+package metis
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/b-harvest/metisian/metis/store"
+	themistypes "github.com/metis-seq/themis/types"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed openapi.yaml
+var openApiSpec []byte
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// apiHandler serves the versioned REST api on the dashboard, openapi.yaml documents it:
+//
+//	GET /api/v1/sequencers                 lists the sequencers
+//	GET /api/v1/sequencers/{name}          stats, signing info and current epoch of a sequencer
+//	GET /api/v1/sequencers/{name}/blocks   sign status per height
+//	GET /api/v1/alerts                     unresolved alarms
+//	GET /api/v1/alerts/history             alerts fired and resolved, needs a history backend
+//	GET /api/v1/nodes                      scores of the rpc nodes
+//	GET /api/v1/epochs                     epochs from the sequencer-set subgraph
+//	GET /api/v1/openapi.yaml               the spec
+func (c *MetisianClient) apiHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/sequencers", c.apiSequencers)
+	mux.HandleFunc("GET /api/v1/sequencers/{name}", c.apiSequencer)
+	mux.HandleFunc("GET /api/v1/sequencers/{name}/blocks", c.apiBlocks)
+	mux.HandleFunc("GET /api/v1/alerts", c.apiAlerts)
+	mux.HandleFunc("GET /api/v1/alerts/history", c.apiAlertHistory)
+	mux.HandleFunc("GET /api/v1/nodes", c.apiNodes)
+	mux.HandleFunc("GET /api/v1/epochs", c.apiEpochs)
+	mux.HandleFunc("GET /api/v1/openapi.yaml", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/yaml")
+		_, _ = writer.Write(openApiSpec)
+	})
+	mux.HandleFunc("/api/v1/", func(writer http.ResponseWriter, request *http.Request) {
+		writeJsonError(writer, http.StatusNotFound, request.URL.Path+" not found")
+	})
+
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		mux.ServeHTTP(writer, request)
+	})
+}
+
+// apiPage is the envelope of every list. NextOffset is only set when there may be more items, Total only when the
+// whole list is known.
+type apiPage struct {
+	Items      interface{} `json:"items"`
+	Limit      int         `json:"limit"`
+	Offset     int         `json:"offset"`
+	NextOffset *int        `json:"next_offset,omitempty"`
+	Total      *int        `json:"total,omitempty"`
+}
+
+// pageOf paginates a list held in memory.
+func pageOf[T any](items []T, limit, offset int) apiPage {
+	total := len(items)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if offset+limit < end {
+		end = offset + limit
+	}
+	p := apiPage{Items: items[offset:end], Limit: limit, Offset: offset, Total: &total}
+	if end < total {
+		p.NextOffset = &end
+	}
+	return p
+}
+
+// storePage wraps a page queried from the history backend.
+func storePage[T any](items []T, f store.Filter) apiPage {
+	p := apiPage{Items: items, Limit: f.Limit, Offset: f.Offset}
+	if len(items) == f.Limit {
+		next := f.Offset + f.Limit
+		p.NextOffset = &next
+	}
+	return p
+}
+
+// apiQuery parses query parameters, keeping the first error.
+type apiQuery struct {
+	values url.Values
+	err    error
+}
+
+func (q *apiQuery) int64(name string) int64 {
+	v := q.values.Get(name)
+	if v == "" || q.err != nil {
+		return 0
+	}
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || i < 0 {
+		q.err = fmt.Errorf("%s must be a positive integer", name)
+	}
+	return i
+}
+
+func (q *apiQuery) time(name string) time.Time {
+	v := q.values.Get(name)
+	if v == "" || q.err != nil {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		q.err = fmt.Errorf("%s must be a RFC 3339 time", name)
+	}
+	return t
+}
+
+// bool returns nil when the parameter isn't set.
+func (q *apiQuery) bool(name string) *bool {
+	v := q.values.Get(name)
+	if v == "" || q.err != nil {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		q.err = fmt.Errorf("%s must be true or false", name)
+	}
+	return &b
+}
+
+func (q *apiQuery) page() (limit, offset int) {
+	limit = int(q.int64("limit"))
+	offset = int(q.int64("offset"))
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize && q.err == nil {
+		q.err = fmt.Errorf("limit can't be more than %d", maxPageSize)
+	}
+	return
+}
+
+type apiSequencer struct {
+	Name              string `json:"name"`
+	Address           string `json:"address"`
+	Jailed            bool   `json:"jailed"`
+	IsProducing       bool   `json:"is_producing"`
+	ActiveAlerts      int    `json:"active_alerts"`
+	ConsecutiveMissed int64  `json:"consecutive_missed"`
+}
+
+type apiSignStats struct {
+	TotalSigns        int64 `json:"total_signs"`
+	TotalProposed     int64 `json:"total_proposed"`
+	TotalMissed       int64 `json:"total_missed"`
+	PrevoteMissed     int64 `json:"prevote_missed"`
+	PrecommitMissed   int64 `json:"precommit_missed"`
+	ConsecutiveMissed int64 `json:"consecutive_missed"`
+}
+
+type apiSequencerDetail struct {
+	apiSequencer
+	LastError    string                 `json:"last_error,omitempty"`
+	Stats        apiSignStats           `json:"stats"`
+	ValInfo      *themistypes.Validator `json:"val_info"`
+	CurrentEpoch *store.EpochRecord     `json:"current_epoch"`
+	Alarms       []apiAlarm             `json:"alarms"`
+}
+
+type apiBlock struct {
+	Height int64      `json:"height"`
+	Status string     `json:"status"`
+	Time   *time.Time `json:"time,omitempty"`
+}
+
+type apiAlarm struct {
+	Sequencer string    `json:"sequencer"`
+	Message   string    `json:"message"`
+	Since     time.Time `json:"since"`
+}
+
+func (c *MetisianClient) apiSequencerOf(seq *Sequencer) apiSequencer {
+	s := apiSequencer{
+		Name:              seq.name,
+		Address:           seq.Address,
+		ActiveAlerts:      c.alarms.getCount(seq.name),
+		ConsecutiveMissed: int64(seq.statConsecutiveMiss),
+	}
+	if seq.valInfo != nil {
+		s.Jailed = seq.valInfo.Jailed
+	}
+	if seq.statSeqData != nil {
+		s.IsProducing = seq.statSeqData.IsNow
+	}
+	return s
+}
+
+// active lists the unresolved alarms, newest first.
+func (a *alarmCache) active(sequencer string) []apiAlarm {
+	a.notifyMux.RLock()
+	defer a.notifyMux.RUnlock()
+	result := make([]apiAlarm, 0)
+	for seqName, messages := range a.AllAlarms {
+		if sequencer != "" && seqName != sequencer {
+			continue
+		}
+		for message, since := range messages {
+			result = append(result, apiAlarm{Sequencer: seqName, Message: message, Since: since})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Since.After(result[j].Since) })
+	return result
+}
+
+// activeAlarms are the unresolved alarms served to a request, redacted like the GraphQL ones.
+func (c *MetisianClient) activeAlarms(ctx context.Context, sequencer string) []apiAlarm {
+	alarms := c.alarms.active(sequencer)
+	for i := range alarms {
+		alarms[i].Message = c.alarmMessage(ctx, alarms[i].Message)
+	}
+	return alarms
+}
+
+// GET /sequencers?jailed=&producing=
+func (c *MetisianClient) apiSequencers(writer http.ResponseWriter, request *http.Request) {
+	q := &apiQuery{values: request.URL.Query()}
+	jailed, producing := q.bool("jailed"), q.bool("producing")
+	limit, offset := q.page()
+	if q.err != nil {
+		writeJsonError(writer, http.StatusBadRequest, q.err.Error())
+		return
+	}
+
+	result := make([]apiSequencer, 0)
+	for _, seq := range c.GetSequencers() {
+		s := c.apiSequencerOf(seq)
+		if (jailed != nil && s.Jailed != *jailed) || (producing != nil && s.IsProducing != *producing) {
+			continue
+		}
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	writeJson(writer, http.StatusOK, pageOf(result, limit, offset))
+}
+
+// GET /sequencers/{name}
+func (c *MetisianClient) apiSequencer(writer http.ResponseWriter, request *http.Request) {
+	seq := c.sequencers()[request.PathValue("name")]
+	if seq == nil || seq.name == MetisianName {
+		writeJsonError(writer, http.StatusNotFound, "unknown sequencer "+request.PathValue("name"))
+		return
+	}
+
+	d := apiSequencerDetail{
+		apiSequencer: c.apiSequencerOf(seq),
+		LastError:    seq.lastError,
+		Stats: apiSignStats{
+			TotalSigns:        int64(seq.statTotalSigns),
+			TotalProposed:     int64(seq.statTotalProps),
+			TotalMissed:       int64(seq.statTotalMiss),
+			PrevoteMissed:     int64(seq.statPrevoteMiss),
+			PrecommitMissed:   int64(seq.statPrecommitMiss),
+			ConsecutiveMissed: int64(seq.statConsecutiveMiss),
+		},
+		ValInfo: seq.valInfo,
+		Alarms:  c.activeAlarms(request.Context(), seq.name),
+	}
+	if c.HideLogs {
+		d.LastError = ""
+	}
+	// the subgraph returns the newest epoch first.
+	if seq.statSeqData != nil && len(seq.statSeqData.Epoches) > 0 {
+		d.CurrentEpoch = epochRecord(c.ChainId, seq, seq.statSeqData.Epoches[0])
+	}
+	writeJson(writer, http.StatusOK, d)
+}
+
+// GET /sequencers/{name}/blocks?from=&to=
+func (c *MetisianClient) apiBlocks(writer http.ResponseWriter, request *http.Request) {
+	q := &apiQuery{values: request.URL.Query()}
+	f := store.Filter{
+		Sequencer:  request.PathValue("name"),
+		FromHeight: q.int64("from"),
+		ToHeight:   q.int64("to"),
+	}
+	f.Limit, f.Offset = q.page()
+	if q.err != nil {
+		writeJsonError(writer, http.StatusBadRequest, q.err.Error())
+		return
+	}
+	if seq := c.sequencers()[f.Sequencer]; seq == nil || seq.name == MetisianName {
+		writeJsonError(writer, http.StatusNotFound, "unknown sequencer "+f.Sequencer)
+		return
+	}
+
+	recs, err := c.signRecords(request.Context(), f)
+	if err != nil {
+		writeJsonError(writer, http.StatusInternalServerError, err.Error())
+		return
+	}
+	blocks := make([]apiBlock, 0, len(recs))
+	for _, rec := range recs {
+		b := apiBlock{Height: rec.Height, Status: rec.Status}
+		if !rec.Time.IsZero() {
+			t := rec.Time
+			b.Time = &t
+		}
+		blocks = append(blocks, b)
+	}
+	writeJson(writer, http.StatusOK, storePage(blocks, f))
+}
+
+// GET /alerts?sequencer=
+func (c *MetisianClient) apiAlerts(writer http.ResponseWriter, request *http.Request) {
+	q := &apiQuery{values: request.URL.Query()}
+	limit, offset := q.page()
+	if q.err != nil {
+		writeJsonError(writer, http.StatusBadRequest, q.err.Error())
+		return
+	}
+	writeJson(writer, http.StatusOK, pageOf(c.activeAlarms(request.Context(), q.values.Get("sequencer")), limit, offset))
+}
+
+// GET /alerts/history?sequencer=&from=&to=
+func (c *MetisianClient) apiAlertHistory(writer http.ResponseWriter, request *http.Request) {
+	if c.history == nil {
+		writeJsonError(writer, http.StatusNotImplemented, "the alert history needs a [history] backend")
+		return
+	}
+	q := &apiQuery{values: request.URL.Query()}
+	f := store.Filter{
+		ChainId:   c.ChainId,
+		Sequencer: q.values.Get("sequencer"),
+		From:      q.time("from"),
+		To:        q.time("to"),
+	}
+	f.Limit, f.Offset = q.page()
+	if q.err != nil {
+		writeJsonError(writer, http.StatusBadRequest, q.err.Error())
+		return
+	}
+
+	recs, err := c.history.store.Alerts(request.Context(), f)
+	if err != nil {
+		writeJsonError(writer, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, rec := range recs {
+		rec.Message = c.alarmMessage(request.Context(), rec.Message)
+	}
+	writeJson(writer, http.StatusOK, storePage(recs, f))
+}
+
+// GET /nodes?down=
+func (c *MetisianClient) apiNodes(writer http.ResponseWriter, request *http.Request) {
+	q := &apiQuery{values: request.URL.Query()}
+	down := q.bool("down")
+	limit, offset := q.page()
+	if q.err != nil {
+		writeJsonError(writer, http.StatusBadRequest, q.err.Error())
+		return
+	}
+
+	scores := c.publicNodeScores()
+	result := make([]NodeScore, 0, len(scores))
+	for _, ns := range scores {
+		if down == nil || ns.Down == *down {
+			result = append(result, ns)
+		}
+	}
+	writeJson(writer, http.StatusOK, pageOf(result, limit, offset))
+}
+
+// GET /epochs?sequencer=&from=&to=
+func (c *MetisianClient) apiEpochs(writer http.ResponseWriter, request *http.Request) {
+	q := &apiQuery{values: request.URL.Query()}
+	f := store.Filter{
+		Sequencer:  q.values.Get("sequencer"),
+		FromHeight: q.int64("from"),
+		ToHeight:   q.int64("to"),
+	}
+	f.Limit, f.Offset = q.page()
+	if q.err != nil {
+		writeJsonError(writer, http.StatusBadRequest, q.err.Error())
+		return
+	}
+
+	recs, err := c.epochRecords(request.Context(), f)
+	if err != nil {
+		writeJsonError(writer, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJson(writer, http.StatusOK, storePage(recs, f))
+}
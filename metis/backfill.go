@@ -0,0 +1,68 @@
+package metis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	"time"
+)
+
+// defaultMaxBackfill is used when max_backfill_blocks isn't set.
+const defaultMaxBackfill = 100
+
+// backfill replays the blocks produced while the websocket was disconnected through the same classification as live
+// blocks, so that the sign stats and the dashboard don't skip them. At most max_backfill_blocks are fetched, the
+// most recent ones when the gap is larger.
+//
+// A block carries the commit of the previous height in last_commit, and the live blocks are classified from it. The
+// backfill does the same with /block so that every commit is counted once: block from carries the commit of the
+// last block seen before the gap, and the first live block after it carries the commit of block to. Classifying from
+// /commit instead would count the commit of to twice and skip the one of from-1.
+func (c *MetisianClient) backfill(ctx context.Context, from, to int64, results chan map[string]StatusUpdate, sequencers map[string]*Sequencer) {
+	maxBackfill, client := c.thresholds().maxBackfill, c.rpcClient()
+	if maxBackfill < 0 || to < from || client == nil {
+		return
+	}
+	if n := to - from + 1; n > int64(maxBackfill) {
+		log.Warn(fmt.Sprintf("⏩ websocket missed %d blocks, only backfilling the last %d", n, maxBackfill))
+		from = to - int64(maxBackfill) + 1
+	}
+	log.With(log.Fields{"from_height": from, "to_height": to}).Info("⏪ backfilling blocks")
+
+	for height := from; height <= to; height++ {
+		b, err := client.getBlock(ctx, height)
+		if err != nil {
+			log.With(log.Fields{"height": height}).Warn(fmt.Sprintf("could not backfill block, skipping %d blocks: %v", to-height+1, err))
+			return
+		}
+		for seqName, upd := range classifyBlock(b, sequencers) {
+			upd.Backfilled = true
+			select {
+			case results <- map[string]StatusUpdate{seqName: upd}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// getBlock fetches a block from the rpc endpoint of the client.
+func (mc *MetisClient) getBlock(ctx context.Context, height int64) (*rawBlock, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	body, err := rpcGet(ctx, mc.rpcUrl, fmt.Sprintf("/block?height=%d", height))
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		Result rawBlock `json:"result"`
+	}
+	if err = json.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Result.Block.Header.Height.val() != height {
+		return nil, fmt.Errorf("node returned block %q", resp.Result.Block.Header.Height)
+	}
+	return &resp.Result, nil
+}
@@ -0,0 +1,111 @@
+package metis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func blockReply(t *testing.T, height int64) *WsReply {
+	t.Helper()
+	reply := &WsReply{}
+	reply.Result.Data.Value = json.RawMessage(fmt.Sprintf(`{"block":{"header":{"height":"%d"}}}`, height))
+	return reply
+}
+
+func TestHandleBlocksReplaysGapsInOrder(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	seq := &Sequencer{Address: "0xabc", name: "seq"}
+	sequencers := func() map[string]*Sequencer { return map[string]*Sequencer{"seq": seq} }
+
+	blocks := make(chan *WsReply)
+	results := make(chan map[string]StatusUpdate, 100)
+	release := make(chan struct{})
+	var gaps [][2]int64
+	backfill := func(from, to int64) {
+		gaps = append(gaps, [2]int64{from, to})
+		// live blocks keep arriving while the gap is fetched.
+		<-release
+		for h := from; h <= to; h++ {
+			results <- map[string]StatusUpdate{"seq": {Height: h, Status: Statusmissed, Final: true, Backfilled: true}}
+		}
+	}
+	go func() {
+		_ = handleBlocks(ctx, blocks, results, sequencers, 10, time.Minute, backfill)
+	}()
+
+	for _, h := range []int64{11, 14, 15, 18} {
+		blocks <- blockReply(t, h)
+	}
+	close(release)
+
+	var heights []int64
+	for len(heights) < 8 {
+		select {
+		case r := <-results:
+			heights = append(heights, r["seq"].Height)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only got heights %v", heights)
+		}
+	}
+	for i, h := range heights {
+		if h != int64(11+i) {
+			t.Fatalf("heights %v are out of order", heights)
+		}
+	}
+	if fmt.Sprint(gaps) != "[[12 13] [16 17]]" {
+		t.Errorf("backfilled %v, want [[12 13] [16 17]]", gaps)
+	}
+}
+
+func TestBackfillClampsToMaxBackfill(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxBackfill int
+		from, to    int64
+		want        []int64
+	}{
+		{"whole gap", 10, 5, 7, []int64{5, 6, 7}},
+		{"most recent blocks of a larger gap", 2, 5, 9, []int64{8, 9}},
+		{"disabled", -1, 5, 7, nil},
+		{"no gap", 10, 8, 7, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetched []int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				h, _ := strconv.ParseInt(r.URL.Query().Get("height"), 10, 64)
+				fetched = append(fetched, h)
+				_, _ = fmt.Fprintf(w, `{"result":{"block":{"header":{"height":"%d","proposer_address":"ABC"}}}}`, h)
+			}))
+			defer srv.Close()
+
+			c := &MetisianClient{MaxBackfill: tt.maxBackfill, client: &MetisClient{rpcUrl: srv.URL}}
+			seq := &Sequencer{Address: "0xabc", name: "seq"}
+			results := make(chan map[string]StatusUpdate, 100)
+			c.backfill(context.Background(), tt.from, tt.to, results, map[string]*Sequencer{"seq": seq})
+			close(results)
+
+			if fmt.Sprint(fetched) != fmt.Sprint(tt.want) {
+				t.Errorf("fetched %v, want %v", fetched, tt.want)
+			}
+			var sent []int64
+			for r := range results {
+				upd := r["seq"]
+				if !upd.Final || !upd.Backfilled || upd.Status != StatusProposed {
+					t.Errorf("unexpected update %+v", upd)
+				}
+				sent = append(sent, upd.Height)
+			}
+			if fmt.Sprint(sent) != fmt.Sprint(tt.want) {
+				t.Errorf("sent %v, want %v", sent, tt.want)
+			}
+		})
+	}
+}
@@ -8,12 +8,14 @@ import (
 	"fmt"
 	"github.com/b-harvest/metisian/log"
 	dash "github.com/b-harvest/metisian/metis/dashboard"
+	"github.com/b-harvest/metisian/metis/store"
 	"github.com/gorilla/websocket"
 	"github.com/machinebox/graphql"
 	stakingtypes "github.com/metis-seq/themis/staking/types"
 	themistypes "github.com/metis-seq/themis/types"
 	"github.com/tendermint/tendermint/rpc/client"
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	"github.com/textileio/go-threads/broadcast"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -33,8 +35,11 @@ const MetisianName = "Metisian"
 type MetisianClient struct {
 	ChainId string
 
-	SequencerSetUrl string
-	L2RpcUrl        string
+	// SequencerSetUrls and L2RpcUrls are tried in order until one answers.
+	SequencerSetUrls []string
+	L2RpcUrls        []string
+	// BlockTime is the expected time between two blocks, zero when unknown.
+	BlockTime time.Duration
 
 	Ctx    context.Context
 	Cancel context.CancelFunc
@@ -42,8 +47,14 @@ type MetisianClient struct {
 	Nodes   []NodeInfo
 	noNodes bool
 
+	pool            RpcPoolConfig
+	consensusAlarms map[string]string
+	preferredRpc    string
+	lastSwitch      time.Time
+
 	NodeDownMin      int
 	NodeDownSeverity string
+	NodeLagBlocks    int
 
 	Stalled       int
 	StalledAlerts bool
@@ -53,9 +64,20 @@ type MetisianClient struct {
 	client *MetisClient
 
 	updateChan chan *dash.SequencerStatus
-	logChan    chan dash.LogMessage
+	// statusCast sends the name of a sequencer to the GraphQL subscriptions when its status changes.
+	statusCast *broadcast.Broadcaster
 
 	alertChan chan *alertMsg // channel used for outgoing notifications
+	alarms    *alarmCache
+
+	silences   []*Silence
+	silenceMux sync.RWMutex
+
+	escalations []*EscalationPolicy
+	outbox      *deliveryQueue
+	startedAt   time.Time
+
+	history *history
 
 	Sequencers map[string]*Sequencer
 
@@ -64,15 +86,33 @@ type MetisianClient struct {
 	lastBlockTime  time.Time
 	lastBlockAlarm bool
 	lastBlockNum   int64
+	MaxBackfill    int
 
 	EnableDash bool
-	Listen     string
 	HideLogs   bool
+
+	// config is the configuration last applied, by newClient or Reload.
+	config *Config
+}
+
+// sequencers returns the sequencers by name. A reload replaces the map and the sequencers it changes rather than
+// updating them, so the map can be read without holding the lock.
+func (c *MetisianClient) sequencers() map[string]*Sequencer {
+	c.seqMux.RLock()
+	defer c.seqMux.RUnlock()
+	return c.Sequencers
+}
+
+// nodes returns the rpc nodes, like sequencers a reload replaces the slice.
+func (c *MetisianClient) nodes() []NodeInfo {
+	c.seqMux.RLock()
+	defer c.seqMux.RUnlock()
+	return c.Nodes
 }
 
 func (c *MetisianClient) GetSequencers() map[string]*Sequencer {
 	var res = map[string]*Sequencer{}
-	for _, seq := range c.Sequencers {
+	for _, seq := range c.sequencers() {
 		if seq.name != MetisianName {
 			res[seq.name] = seq
 		}
@@ -86,22 +126,21 @@ type NodeInfo struct {
 	WsURL       string `toml:"ws_url"`
 	AlertIfDown bool   `toml:"alert_if_down"`
 
-	down      bool
-	wasDown   bool
-	syncing   bool
-	lastMsg   string
-	downSince time.Time
+	health *nodeHealth
 }
 
 type alarmCache struct {
-	SentPdAlarms   map[string]time.Time            `json:"sent_pd_alarms"`
-	SentTgAlarms   map[string]time.Time            `json:"sent_tg_alarms"`
-	SentDiAlarms   map[string]time.Time            `json:"sent_di_alarms"`
-	SentSlkAlarms  map[string]time.Time            `json:"sent_slk_alarms"`
-	SentLarkAlarms map[string]time.Time            `json:"sent_lark_alarms"`
-	AllAlarms      map[string]map[string]time.Time `json:"sent_all_alarms"`
+	// Sent holds the delivered alarms per Notifier name.
+	Sent      map[string]map[string]time.Time `json:"sent_alarms"`
+	AllAlarms map[string]map[string]time.Time `json:"sent_all_alarms"`
+	// Fired holds every unresolved alarm, so that they are restored after a restart.
+	Fired map[string]*firedAlarm `json:"fired_alarms"`
+	// Escalations holds the unresolved alarms with an escalation policy.
+	Escalations    map[string]*escalation `json:"escalations"`
 	flappingAlarms map[string]map[string]time.Time
-	notifyMux      sync.RWMutex
+	// silenced holds the alarms which weren't sent because of a silence, they are sent once it ends.
+	silenced  map[string]*alertMsg
+	notifyMux sync.RWMutex
 }
 
 const (
@@ -117,78 +156,85 @@ const SEPOLIA_L2_RPC_URL = "https://sepolia.metisdevops.link"
 const MAINNET_SEQUENCER_SET_URL = "https://andromeda-subgraph.metisdevops.link/subgraphs/name/metisio/sequencer-set"
 const MAINNET_L2_RPC_URL = "https://andromeda.metis.io"
 
-func NewClient(cfg *Config) (*MetisianClient, error) {
+// builtinNetworks are the public networks, a [network] section only needs their chain id.
+var builtinNetworks = map[string]NetworkConfig{
+	MAINNET_CHAIN_ID: {
+		ChainId:          MAINNET_CHAIN_ID,
+		SequencerSetUrls: []string{MAINNET_SEQUENCER_SET_URL},
+		L2RpcUrls:        []string{MAINNET_L2_RPC_URL},
+	},
+	SEPOLIA_CHAIN_ID: {
+		ChainId:          SEPOLIA_CHAIN_ID,
+		SequencerSetUrls: []string{SEPOLIA_SEQUENCER_SET_URL},
+		L2RpcUrls:        []string{SEPOLIA_L2_RPC_URL},
+	},
+}
+
+// newClient creates the client of a network, it is cancelled along with ctx and sends the status of its sequencers
+// to updates.
+func newClient(ctx context.Context, cfg *Config, updates chan *dash.SequencerStatus) (*MetisianClient, error) {
 	var (
 		client MetisianClient
 		err    error
 	)
 
-	// Check if configurations are valid
-	if cfg.EnableDash {
-		_, err = url.Parse(cfg.Listen)
-		if err != nil || cfg.Listen == "" {
-			log.Fatal(errors.New(fmt.Sprintf("error: The listen URL %s does not appear to be valid", cfg.Listen)))
-		}
+	if len(cfg.Networks) > 0 {
+		return nil, errors.New("a configuration with [[networks]] needs a supervisor")
 	}
-
-	if cfg.NodeDownMin < 3 {
-		log.Fatal(errors.New("warning: setting 'node_down_alert_minutes' to less than three minutes might result in false alarms"))
+	if err = cfg.validate(); err != nil {
+		return nil, err
 	}
 
 	client.alertChan = make(chan *alertMsg)
-	client.logChan = make(chan dash.LogMessage)
-	client.updateChan = make(chan *dash.SequencerStatus, len(client.Sequencers)*2)
-	client.Ctx, client.Cancel = context.WithCancel(context.Background())
+	client.alarms = newAlarmCache()
+	client.statusCast = broadcast.NewBroadcaster(16)
+	client.updateChan = updates
+	client.Ctx, client.Cancel = context.WithCancel(ctx)
 
-	// configure sequencers
-	client.Sequencers = map[string]*Sequencer{}
-	for _, seqInfo := range cfg.Sequencers {
-		if seqInfo.Alerts.UseParent {
-			seqInfo.Alerts.Pagerduty = cfg.Pagerduty
-			seqInfo.Alerts.Discord = cfg.Discord
-			seqInfo.Alerts.Telegram = cfg.Telegram
-			seqInfo.Alerts.Slack = cfg.Slack
-			seqInfo.Alerts.Lark = cfg.Lark
-		}
+	client.Sequencers = newSequencers(cfg)
+	client.Nodes = cfg.NodeInfos
+	for i := range client.Nodes {
+		client.Nodes[i].health = &nodeHealth{}
+	}
+	// validated
+	network, _ := cfg.network()
+	client.ChainId = network.ChainId
+	client.SequencerSetUrls = network.SequencerSetUrls
+	client.L2RpcUrls = network.L2RpcUrls
+	client.BlockTime = time.Duration(network.BlockTimeSeconds * float64(time.Second))
+
+	client.consensusAlarms = make(map[string]string)
+	client.configure(cfg)
+	client.EnableDash = cfg.EnableDash
+	client.HideLogs = cfg.HideLogs
+	client.config = cfg
 
-		seq := NewSequencer(seqInfo)
-		client.Sequencers[seqInfo.Name] = &seq
+	retry := cfg.Retry
+	if retry.MaxAttempts <= 0 {
+		retry.MaxAttempts = 10
 	}
-	manager := NewSequencer(
-		SequencerInfo{
-			Address: MetisianName,
-			Name:    MetisianName,
-			Alerts: AlertConfig{
-				Pagerduty: cfg.Pagerduty,
-				Discord:   cfg.Discord,
-				Telegram:  cfg.Telegram,
-				Slack:     cfg.Slack,
-				Lark:      cfg.Lark,
-			},
-		})
-	client.Sequencers[MetisianName] = &manager
-
-	client.Nodes = cfg.NodeInfos
-	if cfg.ChainId == MAINNET_CHAIN_ID {
-		client.ChainId = cfg.ChainId
-		client.SequencerSetUrl = MAINNET_SEQUENCER_SET_URL
-		client.L2RpcUrl = MAINNET_L2_RPC_URL
-	} else if cfg.ChainId == SEPOLIA_CHAIN_ID {
-		client.ChainId = cfg.ChainId
-		client.SequencerSetUrl = SEPOLIA_SEQUENCER_SET_URL
-		client.L2RpcUrl = SEPOLIA_L2_RPC_URL
-	} else {
-		return nil, errors.New(fmt.Sprintf("chain id doesn't matched. you should set either %s or %s", MAINNET_CHAIN_ID, SEPOLIA_CHAIN_ID))
+	if retry.InitialBackoffSeconds <= 0 {
+		retry.InitialBackoffSeconds = 5
+	}
+	if retry.MaxBackoffSeconds < retry.InitialBackoffSeconds {
+		retry.MaxBackoffSeconds = 600
 	}
+	if retry.QueueFile == "" {
+		retry.QueueFile = DefaultQueueFile
+	}
+	if retry.DeadLetterFile == "" {
+		retry.DeadLetterFile = DefaultDeadLetterFile
+	}
+	client.outbox = newDeliveryQueue(retry, client.alarms, client.destinationsFor)
+	client.startedAt = time.Now()
 
-	client.NodeDownMin = cfg.NodeDownMin
-	client.NodeDownSeverity = cfg.NodeDownSeverity
-	client.Stalled = cfg.Stalled
-	client.StalledAlerts = cfg.StalledAlerts
-	client.AlertIfNoServers = cfg.AlertIfNoServers
-	client.EnableDash = cfg.EnableDash
-	client.Listen = cfg.Listen
-	client.HideLogs = cfg.HideLogs
+	if cfg.History.Backend != "" {
+		s, err := store.Open(cfg.History.Backend, cfg.History.Dsn, cfg.History.Path, cfg.History.MaxFileMB)
+		if err != nil {
+			return nil, fmt.Errorf("opening history: %w", err)
+		}
+		client.history = newHistory(s)
+	}
 
 	sf, e := os.OpenFile(cfg.StateFile, os.O_RDONLY, 0600)
 	if e != nil {
@@ -210,72 +256,152 @@ func NewClient(cfg *Config) (*MetisianClient, error) {
 		}
 	}
 
+	for _, node := range client.Nodes {
+		if since, ok := saved.NodesDown[node.RpcURL]; ok {
+			node.health.markDownSince(since)
+		}
+	}
+
 	for seq, seqData := range saved.Sequencers {
 		if client.Sequencers[seq] != nil {
 			client.Sequencers[seq].statSeqData = &seqData
 		}
 	}
 
+	client.restoreAlarms(saved.Alarms)
+
+	client.applyConfigSilences(cfg.Silences)
+	for _, s := range saved.Silences {
+		if e = client.addSilence(s); e != nil {
+			log.Debug(fmt.Sprintf("dropping saved silence %s: %v", s.Id, e))
+		}
+	}
+
 	return &client, nil
 }
 
+// newSequencers creates the sequencers of a configuration, along with Metisian itself which alerts through the
+// default destinations.
+func newSequencers(cfg *Config) map[string]*Sequencer {
+	sequencers := map[string]*Sequencer{}
+	for _, seqInfo := range cfg.Sequencers {
+		if seqInfo.UseParent || seqInfo.Alerts.UseParent {
+			seqInfo.Alerts.UseParent = true
+			seqInfo.Alerts.Destinations = cfg.Destinations
+		}
+
+		seq := NewSequencer(seqInfo)
+		sequencers[seqInfo.Name] = &seq
+	}
+	manager := NewSequencer(
+		SequencerInfo{
+			Address: MetisianName,
+			Name:    MetisianName,
+			Alerts: AlertConfig{
+				Destinations: cfg.Destinations,
+			},
+		})
+	sequencers[MetisianName] = &manager
+	return sequencers
+}
+
+// configure applies the settings which can change while running, see Reload.
+func (c *MetisianClient) configure(cfg *Config) {
+	c.pool = cfg.RpcPool
+	if c.pool.MaxLagBlocks <= 0 {
+		c.pool.MaxLagBlocks = 5
+	}
+	if c.pool.SwitchMargin <= 0 {
+		c.pool.SwitchMargin = 20
+	}
+	if c.pool.MinSwitchMinutes <= 0 {
+		c.pool.MinSwitchMinutes = 10
+	}
+
+	c.NodeDownMin = cfg.NodeDownMin
+	c.NodeDownSeverity = cfg.NodeDownSeverity
+	c.NodeLagBlocks = cfg.NodeLagBlocks
+	if c.NodeLagBlocks <= 0 {
+		c.NodeLagBlocks = defaultNodeLagBlocks
+	}
+	c.Stalled = cfg.Stalled
+	c.StalledAlerts = cfg.StalledAlerts
+	c.MaxBackfill = cfg.MaxBackfill
+	if c.MaxBackfill == 0 {
+		c.MaxBackfill = defaultMaxBackfill
+	}
+	c.AlertIfNoServers = cfg.AlertIfNoServers
+	c.escalations = cfg.Escalations
+}
+
+// thresholds are the settings of configure, a reload may change them while the monitoring goroutines read them.
+type thresholds struct {
+	nodeDownMin      int
+	nodeDownSeverity string
+	nodeLagBlocks    int
+	stalled          int
+	stalledAlerts    bool
+	alertIfNoServers bool
+	maxBackfill      int
+	pool             RpcPoolConfig
+	escalations      []*EscalationPolicy
+}
+
+// thresholds returns the current settings of configure.
+func (c *MetisianClient) thresholds() thresholds {
+	c.seqMux.RLock()
+	defer c.seqMux.RUnlock()
+	return thresholds{
+		nodeDownMin:      c.NodeDownMin,
+		nodeDownSeverity: c.NodeDownSeverity,
+		nodeLagBlocks:    c.NodeLagBlocks,
+		stalled:          c.Stalled,
+		stalledAlerts:    c.StalledAlerts,
+		alertIfNoServers: c.AlertIfNoServers,
+		maxBackfill:      c.MaxBackfill,
+		pool:             c.pool,
+		escalations:      c.escalations,
+	}
+}
+
+// rpcClient returns the client of the active rpc node, nil until one is connected.
+func (c *MetisianClient) rpcClient() *MetisClient {
+	c.seqMux.RLock()
+	defer c.seqMux.RUnlock()
+	return c.client
+}
+
 func (c *MetisianClient) Run() {
 
+	c.outbox.start(c.Ctx)
+	if c.history != nil {
+		go c.history.run(c.Ctx)
+		log.Info("📚 recording history")
+	}
 	go func() {
 		for {
 			select {
 			case alert := <-c.alertChan:
-				go func(msg *alertMsg) {
-					var e error
-					e = notifyPagerduty(msg)
-					if e != nil {
-						log.ErrorDynamicArgs(msg.sequencer, "error sending alert to pagerduty", e.Error())
-					}
-					e = notifyDiscord(msg)
-					if e != nil {
-						log.ErrorDynamicArgs(msg.sequencer, "error sending alert to discord", e.Error())
-					}
-					e = notifyTg(msg)
-					if e != nil {
-						log.ErrorDynamicArgs(msg.sequencer, "error sending alert to telegram", e.Error())
-					}
-					e = notifySlack(msg)
-					if e != nil {
-						log.ErrorDynamicArgs(msg.sequencer, "error sending alert to slack", e.Error())
-					}
-					e = notifyLark(msg)
-					if e != nil {
-						log.ErrorDynamicArgs(msg.sequencer, "error sending alert to lark", e.Error())
-					}
-				}(alert)
+				c.outbox.notify(alert)
 			case <-c.Ctx.Done():
 				return
 			}
 		}
 	}()
 
-	if c.EnableDash {
-		go dash.Serve(c.Listen, c.updateChan, c.logChan, c.HideLogs)
-		log.Info("⚙️ starting dashboard on " + c.Listen)
-	} else {
-		go func() {
-			for {
-				<-c.updateChan
-			}
-		}()
+	if tg := c.sequencers()[MetisianName].Alerts.Telegram; tg.Commands {
+		go c.runTelegramBot(c.Ctx, tg)
 	}
 
 	for _, seq := range c.GetSequencers() {
 		if c.EnableDash {
 			if seq.blocksResults == nil {
-				seq.blocksResults = make([]int, showBlocks)
-				for i := range seq.blocksResults {
-					seq.blocksResults[i] = -1
-				}
+				seq.blocksResults = newBlocksResults()
 			}
 
 			c.updateChan <- &dash.SequencerStatus{
 				MsgType:      "status",
+				ChainId:      c.ChainId,
 				Name:         seq.name,
 				Address:      seq.Address,
 				Jailed:       false, // TODO replace `false` to seq.valInfo.jailed when save file logic has implemented.
@@ -287,6 +413,12 @@ func (c *MetisianClient) Run() {
 
 	go c.watch()
 
+	// escalation policies may be added by a reload.
+	go c.escalate(c.Ctx)
+
+	// alarms still active when their silence ends are sent then.
+	go c.watchSilences(c.Ctx)
+
 	// node health checks:
 	go func() {
 		for {
@@ -314,18 +446,37 @@ func (c *MetisianClient) Run() {
 			log.ErrorDynamicArgs("🛑", e)
 		}
 		c.WsRun()
-		c.client.wsConn.Close()
+		c.rpcClient().wsConn.Close()
 		log.Warn("🌀 websocket exited! Restarting monitoring")
 		time.Sleep(5 * time.Second)
 
 	}
 }
 
+// handle registers the api of the client on the dashboard, under prefix (ex. /networks/andromeda).
+func (c *MetisianClient) handle(prefix string) {
+	handle := func(pattern string, handler http.Handler, access dash.Access) {
+		if prefix != "" {
+			handler = http.StripPrefix(prefix, handler)
+		}
+		dash.Handle(prefix+pattern, handler, access)
+	}
+	handle("/silences", c.silenceHandler(), dash.ReadOnly)
+	handle("/silences/", c.silenceHandler(), dash.ReadOnly)
+	// dead letters hold the full alert messages.
+	handle("/dead-letters", c.deadLetterHandler(), dash.Operator)
+	handle("/dead-letters/", c.deadLetterHandler(), dash.Operator)
+	handle("/nodes", c.nodesHandler(), dash.ReadOnly)
+	handle("/api/v1/", c.apiHandler(), dash.ReadOnly)
+	// queries are sent over POST, the schema has no mutation.
+	handle("/graphql", c.graphqlHandler(), dash.Public)
+	handle("/graphql/playground", graphqlPlayground(prefix+"/graphql"), dash.ReadOnly)
+}
+
 type MetisClient struct {
-	rpcUrl       string
-	wsConn       *websocket.Conn
-	seqSetClient graphql.Client
-	l2RpcUrl     string
+	rpcUrl        string
+	wsConn        *websocket.Conn
+	seqSetClients []*graphql.Client
 }
 
 func NewMetisClient(nodeInfo NodeInfo, c *MetisianClient) (*MetisClient, error) {
@@ -357,8 +508,9 @@ func NewMetisClient(nodeInfo NodeInfo, c *MetisianClient) (*MetisClient, error)
 
 	mc.wsConn = conn
 
-	mc.seqSetClient = *graphql.NewClient(c.SequencerSetUrl, graphql.WithHTTPClient(&http.Client{Timeout: 10 * time.Second}))
-	mc.l2RpcUrl = c.L2RpcUrl
+	for _, seqSetUrl := range c.SequencerSetUrls {
+		mc.seqSetClients = append(mc.seqSetClients, graphql.NewClient(seqSetUrl, graphql.WithHTTPClient(&http.Client{Timeout: 10 * time.Second})))
+	}
 
 	return &mc, nil
 }
@@ -420,7 +572,27 @@ func (c *MetisianClient) GetAnySequencer() *Sequencer {
 	panic("Cannot find any sequencer!!")
 }
 
-func (c *MetisianClient) GetEthBlockNumber() (int64, error) {
+// runSequencerSet queries the sequencer-set subgraphs in order, until one answers.
+func (mc *MetisClient) runSequencerSet(ctx context.Context, req *graphql.Request, resp interface{}) (err error) {
+	for _, seqSetClient := range mc.seqSetClients {
+		if err = seqSetClient.Run(ctx, req, resp); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// GetEthBlockNumber returns the current l2 block number, from the first l2 rpc answering.
+func (c *MetisianClient) GetEthBlockNumber() (n int64, err error) {
+	for _, l2RpcUrl := range c.L2RpcUrls {
+		if n, err = ethBlockNumber(l2RpcUrl); err == nil {
+			return n, nil
+		}
+	}
+	return 0, err
+}
+
+func ethBlockNumber(l2RpcUrl string) (int64, error) {
 	jsonBody := map[string]interface{}{
 		"method":  "eth_blockNumber",
 		"params":  []interface{}{},
@@ -432,7 +604,7 @@ func (c *MetisianClient) GetEthBlockNumber() (int64, error) {
 	if err != nil {
 		return 0, err
 	}
-	req, err := http.NewRequest("POST", c.L2RpcUrl, bytes.NewBuffer(body))
+	req, err := http.NewRequest("POST", l2RpcUrl, bytes.NewBuffer(body))
 	if err != nil {
 		return 0, err
 	}
@@ -457,8 +629,8 @@ func (c *MetisianClient) GetEthBlockNumber() (int64, error) {
 	}
 
 	resultHex, ok := resBody["result"].(string)
-	if !ok {
-		return 0, err
+	if !ok || len(resultHex) < 3 {
+		return 0, fmt.Errorf("no block number in the eth_blockNumber response of %s", l2RpcUrl)
 	}
 
 	resultInt, err := strconv.ParseInt(resultHex[2:], 16, 64) // Strip "0x" and convert
@@ -476,11 +648,13 @@ type savedState struct {
 	Blocks     map[string][]int     `json:"blocks"`
 	NodesDown  map[string]time.Time `json:"nodes_down"`
 	Sequencers map[string]SeqData   `json:"sequencers"`
+	Silences   []*Silence           `json:"silences"`
 }
 
 func (c *MetisianClient) SaveOnExit(stateFile string, saved chan interface{}) {
 	quitting := make(chan os.Signal, 1)
-	signal.Notify(quitting, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	// SIGHUP reloads the configuration and the dashboard certificate.
+	signal.Notify(quitting, syscall.SIGINT, syscall.SIGTERM)
 
 	saveState := func() {
 		defer close(saved)
@@ -502,11 +676,8 @@ func (c *MetisianClient) SaveOnExit(stateFile string, saved chan interface{}) {
 		}
 		nodesDown := make(map[string]time.Time)
 		for _, node := range c.Nodes {
-			if node.down {
-				if nodesDown == nil {
-					nodesDown = make(map[string]time.Time)
-				}
-				nodesDown[node.RpcURL] = node.downSince
+			if st := node.health.snapshot(); st.down {
+				nodesDown[node.RpcURL] = st.downSince
 			}
 		}
 
@@ -523,12 +694,22 @@ func (c *MetisianClient) SaveOnExit(stateFile string, saved chan interface{}) {
 			sequencers[seq.name] = *stat
 		}
 
+		silences := make([]*Silence, 0)
+		for _, s := range c.getSilences() {
+			if !s.FromConfig {
+				silences = append(silences, s)
+			}
+		}
+
+		c.alarms.notifyMux.RLock()
 		b, e := json.Marshal(&savedState{
-			Alarms:     alarms,
+			Alarms:     c.alarms,
 			Blocks:     blocks,
 			NodesDown:  nodesDown,
 			Sequencers: sequencers,
+			Silences:   silences,
 		})
+		c.alarms.notifyMux.RUnlock()
 		if e != nil {
 			log.Error(e)
 			return
@@ -3,14 +3,17 @@ package metis
 import (
 	"errors"
 	"fmt"
+	dash "github.com/b-harvest/metisian/metis/dashboard"
 	"github.com/b-harvest/metisian/util"
 	"github.com/pelletier/go-toml/v2"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
 type Config struct {
-	StateFile string
+	StateFile string `toml:"-"`
 	// What metisian watching
 	Sequencers []SequencerInfo `toml:"sequencers"`
 
@@ -19,28 +22,44 @@ type Config struct {
 	NodeDownMin int `toml:"node_down_alert_minutes"`
 	// NodeDownSeverity controls the Pagerduty severity when notifying if a node is down.
 	NodeDownSeverity string `toml:"node_down_alert_severity"`
+	// NodeLagBlocks controls how many blocks a node may be behind its peers before alerting, defaults to 10.
+	NodeLagBlocks int `toml:"node_lag_alert_blocks"`
 
 	Stalled int `toml:"stalled_minutes"`
 
 	StalledAlerts bool `toml:"stalled_enabled"`
 
+	// MaxBackfill bounds how many blocks missed while the websocket was disconnected are fetched over rpc, defaults to
+	// 100 and -1 disables the backfill.
+	MaxBackfill int `toml:"max_backfill_blocks"`
+
 	// AlertIfNoServers: should an alert be sent if no servers are reachable?
 	AlertIfNoServers bool `toml:"alert_if_no_servers"`
 
 	NodeInfos []NodeInfo `toml:"node_infos"`
-	ChainId   string     `toml:"chain_id"` // sepolia-1, andromeda
+	// RpcPool controls how the websocket subscription moves between nodes.
+	RpcPool RpcPoolConfig `toml:"rpc_pool"`
 
-	// sequencer specific overrides for alert destinations.
-	// Pagerduty configuration values
-	Pagerduty PDConfig `toml:"pagerduty"`
-	// Discord webhook information
-	Discord DiscordConfig `toml:"discord"`
-	// Telegram webhook information
-	Telegram TeleConfig `toml:"telegram"`
-	// Slack webhook information
-	Slack SlackConfig `toml:"slack"`
-	// LarkConfig webhook information
-	Lark LarkConfig `toml:"lark"`
+	// Silences mute alerts, either until a date or during a recurring maintenance window.
+	Silences []*Silence `toml:"silences"`
+	// Escalations re-notify unresolved alarms, the first policy matching an alarm is used.
+	Escalations []*EscalationPolicy `toml:"escalations"`
+	ChainId     string              `toml:"chain_id"` // sepolia-1, andromeda
+	// Network sets up another network than the built-in ones, or replaces their urls.
+	Network NetworkConfig `toml:"network"`
+	// Networks monitors several networks from one process. Each [[networks]] table holds the chain_id, sequencers,
+	// node_infos and any other setting of a network, the top level settings are the defaults of every network.
+	Networks []map[string]interface{} `toml:"networks"`
+	// networks are the configurations of the [[networks]] tables, resolved by LoadConfig.
+	networks []*Config
+
+	// default alert destinations, also used by sequencers with use_parent.
+	Destinations
+	// Retry controls how failed notifications are retried.
+	Retry RetryConfig `toml:"notification_retry"`
+	// History keeps the sign status, epochs, alerts and node events in a database, it is disabled when no backend is
+	// set.
+	History HistoryConfig `toml:"history"`
 
 	// EnableDash enables the web dashboard
 	EnableDash bool `toml:"enable_dashboard"`
@@ -49,6 +68,13 @@ type Config struct {
 	// HideLogs controls whether logs are sent to the dashboard. It will also suppress many alarm details.
 	// This is useful if the dashboard will be public.
 	HideLogs bool `toml:"hide_logs"`
+	// Dashboard sets up tls, authentication and cors for the dashboard.
+	Dashboard dash.Config `toml:"dashboard"`
+
+	// EnablePrometheus enables the prometheus exporter
+	EnablePrometheus bool `toml:"enable_prometheus"`
+	// PrometheusListen is the address the /metrics endpoint listens on (ex. ":9100")
+	PrometheusListen string `toml:"prometheus_listen"`
 }
 
 type AlertConfig struct {
@@ -75,6 +101,13 @@ type AlertConfig struct {
 	NotifyMining bool `toml:"notify_mining"`
 
 	// sequencer specific overrides for alert destinations.
+	Destinations
+}
+
+// Destinations holds the configuration of every alert destination. It is embedded both in Config and in
+// AlertConfig, so that a sequencer using use_parent inherits all of them at once. Adding a destination only takes a
+// section here and a Notifier reading it.
+type Destinations struct {
 	// Pagerduty configuration values
 	Pagerduty PDConfig `toml:"pagerduty"`
 	// Discord webhook information
@@ -85,87 +118,427 @@ type AlertConfig struct {
 	Slack SlackConfig `toml:"slack"`
 	// LarkConfig webhook information
 	Lark LarkConfig `toml:"lark"`
+	// Webhook generic http endpoint information
+	Webhook WebhookConfig `toml:"webhook"`
+	// Email smtp server information
+	Email EmailConfig `toml:"email"`
+	// Opsgenie configuration values
+	Opsgenie OpsgenieConfig `toml:"opsgenie"`
+	// Alertmanager is a prometheus alertmanager receiving alerts through its v2 api
+	Alertmanager AlertmanagerConfig `toml:"alertmanager"`
+	// Matrix room information
+	Matrix MatrixConfig `toml:"matrix"`
+	// Mattermost webhook information
+	Mattermost MattermostConfig `toml:"mattermost"`
+	// Teams webhook information
+	Teams TeamsConfig `toml:"teams"`
+}
+
+// NetworkConfig describes the network metisian follows. andromeda and sepolia-1 are built in, any other chain id needs
+// its sequencer-set subgraph and l2 rpc urls. Set for a built-in network, the urls replace its defaults (ex. a local
+// subgraph mirror).
+type NetworkConfig struct {
+	// ChainId may also be set by the top level chain_id.
+	ChainId string `toml:"chain_id"`
+	// SequencerSetUrls are the sequencer-set subgraph endpoints, tried in order.
+	SequencerSetUrls []string `toml:"sequencer_set_urls"`
+	// L2RpcUrls are the l2 json-rpc endpoints giving the current l2 block, tried in order.
+	L2RpcUrls []string `toml:"l2_rpc_urls"`
+	// BlockTimeSeconds is the expected time between two blocks. The websocket is only considered dead after ten
+	// block times without a block, and never sooner than a minute.
+	BlockTimeSeconds float64 `toml:"block_time_seconds"`
+}
+
+// RpcPoolConfig controls the failover of the websocket subscription to the best scored node.
+type RpcPoolConfig struct {
+	// MaxLagBlocks fails over at once when the active node is more than this many blocks behind the highest node.
+	MaxLagBlocks int `toml:"max_lag_blocks"`
+	// SwitchMargin is how many points (out of 100) a node must score above a healthy active node to move to it.
+	SwitchMargin float64 `toml:"switch_margin"`
+	// MinSwitchMinutes is the minimum time between two moves to a better scored node.
+	MinSwitchMinutes int `toml:"min_switch_minutes"`
+}
+
+// HistoryConfig selects the history backend: "mysql" connects to dsn, "file" writes json lines to the path
+// directory.
+type HistoryConfig struct {
+	Backend string `toml:"backend"`
+	Dsn     string `toml:"dsn" secret:"true"`
+	Path    string `toml:"path"`
+	// MaxFileMB is the size after which a file of the file backend is rotated, only the previous file is kept.
+	MaxFileMB int `toml:"max_file_mb"`
+}
+
+// RetryConfig controls the retries of notifications which couldn't be delivered. The delay between attempts to a
+// destination doubles from initial_backoff_seconds up to max_backoff_seconds, after max_attempts the notification is
+// appended to the dead-letter file.
+type RetryConfig struct {
+	MaxAttempts           int    `toml:"max_attempts"`
+	InitialBackoffSeconds int    `toml:"initial_backoff_seconds"`
+	MaxBackoffSeconds     int    `toml:"max_backoff_seconds"`
+	QueueFile             string `toml:"queue_file"`
+	DeadLetterFile        string `toml:"dead_letter_file"`
 }
 
 // PDConfig is the information required to send alerts to PagerDuty
 type PDConfig struct {
 	Enabled         bool   `toml:"enabled"`
-	ApiKey          string `toml:"api_key"`
+	ApiKey          string `toml:"api_key" secret:"true"`
 	DefaultSeverity string `toml:"default_severity"`
 }
 
 // DiscordConfig holds the information needed to publish to a Discord webhook for sending alerts
 type DiscordConfig struct {
 	Enabled  bool     `toml:"enabled"`
-	Webhook  string   `toml:"webhook"`
+	Webhook  string   `toml:"webhook" secret:"true"`
 	Mentions []string `toml:"mentions"`
 }
 
 // TeleConfig holds the information needed to publish to a Telegram webhook for sending alerts
 type TeleConfig struct {
 	Enabled  bool     `toml:"enabled"`
-	ApiKey   string   `toml:"api_key"`
+	ApiKey   string   `toml:"api_key" secret:"true"`
 	Channel  string   `toml:"channel"`
 	Mentions []string `toml:"mentions"`
+
+	// Commands starts a bot answering /status, /alerts, /nodes, /epochs and /silence in the channel (or group).
+	// Only used in the top level [telegram] section.
+	Commands bool `toml:"commands"`
+	// AllowedUsers are the telegram user ids allowed to use commands, nobody is allowed when empty.
+	AllowedUsers []int64 `toml:"allowed_users"`
 }
 
 // SlackConfig holds the information needed to publish to a Slack webhook for sending alerts
 type SlackConfig struct {
 	Enabled  bool     `toml:"enabled"`
-	Webhook  string   `toml:"webhook"`
+	Webhook  string   `toml:"webhook" secret:"true"`
 	Mentions []string `toml:"mentions"`
 }
 
 // LarkConfig holds the information needed to publish to a Lark webhook for sending alerts
 type LarkConfig struct {
 	Enabled bool   `toml:"enabled"`
-	Webhook string `toml:"webhook"`
+	Webhook string `toml:"webhook" secret:"true"`
+}
+
+// WebhookConfig holds the information needed to post alerts to any http endpoint
+type WebhookConfig struct {
+	Enabled bool   `toml:"enabled"`
+	Url     string `toml:"url"`
+	// Method defaults to POST
+	Method string `toml:"method"`
+	// ContentType of the rendered body, defaults to application/json. Any json content type is validated before
+	// it's sent.
+	ContentType string `toml:"content_type"`
+	// Template is a go text/template rendered with the alert, the available fields are .Sequencer, .Message,
+	// .Severity, .Resolved, .UniqueId and .ChainId, and `json` quotes a value. By default, all fields are sent as a
+	// json object.
+	Template string            `toml:"template"`
+	Headers  map[string]string `toml:"headers" secret:"true"`
+	// Secret enables a hex encoded HMAC-SHA256 signature of the body, sent as "sha256=<signature>".
+	Secret string `toml:"secret" secret:"true"`
+	// SignatureHeader defaults to X-Metisian-Signature
+	SignatureHeader string `toml:"signature_header"`
+}
+
+// EmailConfig holds the information needed to send alerts by mail through an SMTP server
+type EmailConfig struct {
+	Enabled bool   `toml:"enabled"`
+	Host    string `toml:"host"`
+	Port    int    `toml:"port"`
+	// StartTLS upgrades the connection after connecting, TLS connects with implicit TLS (usually port 465).
+	// Leave both off for a local sink such as mailhog.
+	StartTLS bool `toml:"starttls"`
+	TLS      bool `toml:"tls"`
+	// Username and Password enable PLAIN auth, only sent over TLS or to localhost.
+	Username string   `toml:"username"`
+	Password string   `toml:"password" secret:"true"`
+	From     string   `toml:"from"`
+	To       []string `toml:"to"`
+}
+
+// OpsgenieConfig is the information required to create and close alerts with the Opsgenie alerts api
+type OpsgenieConfig struct {
+	Enabled bool   `toml:"enabled"`
+	ApiKey  string `toml:"api_key" secret:"true"`
+	// ApiUrl defaults to https://api.opsgenie.com, use https://api.eu.opsgenie.com for the EU instance.
+	ApiUrl string   `toml:"api_url"`
+	Tags   []string `toml:"tags"`
+}
+
+// AlertmanagerConfig holds the information needed to post alerts to a prometheus alertmanager
+type AlertmanagerConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Url of the alertmanager, without /api/v2/alerts (ex. http://alertmanager:9093)
+	Url string `toml:"url"`
+	// Labels are added to every alert, in addition to sequencer, address, chain_id and severity.
+	Labels map[string]string `toml:"labels"`
+	// Username and Password enable basic auth
+	Username string `toml:"username"`
+	Password string `toml:"password" secret:"true"`
+}
+
+// MatrixConfig holds the information needed to post alerts to a Matrix room
+type MatrixConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Homeserver is the client-server api base url (ex. https://matrix.org)
+	Homeserver  string `toml:"homeserver"`
+	AccessToken string `toml:"access_token" secret:"true"`
+	// RoomId is the internal room id (ex. !abcdefg:matrix.org), not an alias
+	RoomId   string   `toml:"room_id"`
+	Mentions []string `toml:"mentions"`
+}
+
+// MattermostConfig holds the information needed to publish to a Mattermost incoming webhook for sending alerts
+type MattermostConfig struct {
+	Enabled bool   `toml:"enabled"`
+	Webhook string `toml:"webhook" secret:"true"`
+	// Channel overrides the default channel of the webhook
+	Channel  string   `toml:"channel"`
+	Mentions []string `toml:"mentions"`
+}
+
+// TeamsConfig holds the information needed to publish to a Microsoft Teams webhook for sending alerts
+type TeamsConfig struct {
+	Enabled  bool     `toml:"enabled"`
+	Webhook  string   `toml:"webhook" secret:"true"`
+	Mentions []string `toml:"mentions"`
 }
 
 type SequencerInfo struct {
 	// Sequencer's address you'll watch. (ex. 0x81fc9d26d6b234f9cc6a84bcfefc679cb64a227a)
 	Address string `toml:"address"`
 	Name    string `toml:"Name"`
+	// UseParent sends the alerts of this sequencer to the default destinations, like alerts.use_parent.
+	UseParent bool `toml:"use_parent"`
 
 	// Alerts defines the types of alerts to send for this sequencer.
 	Alerts AlertConfig `toml:"alerts"`
 }
 
 func LoadConfig(filePath, token, stateFilePath string) (*Config, error) {
-
+	b, err := readConfig(filePath, token)
+	if err != nil {
+		return nil, err
+	}
 	cfg := &Config{}
+	if err = toml.Unmarshal(b, cfg); err != nil {
+		return nil, err
+	}
+
+	cfg.StateFile = stateFilePath
+	if err = cfg.loadNetworks(b); err != nil {
+		return nil, err
+	}
+	if err = cfg.resolveSecrets(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadNetworks resolves the configuration of every [[networks]] table from the file b: the top level settings, with
+// the tables of the network merged over them and its arrays of tables (ex. sequencers, silences) replacing them.
+// The state, queue and dead-letter files, and the file history, are namespaced by the chain id of the network.
+func (cfg *Config) loadNetworks(b []byte) error {
+	cfg.networks = nil
+	for i, table := range cfg.Networks {
+		n := &Config{}
+		if err := toml.Unmarshal(b, n); err != nil {
+			return err
+		}
+		n.Networks = nil
+		nb, err := toml.Marshal(table)
+		if err == nil {
+			err = toml.Unmarshal(nb, n)
+		}
+		if err != nil {
+			return fmt.Errorf("[[networks]] %d: %w", i+1, err)
+		}
+
+		n.StateFile = cfg.StateFile
+		if chainId := n.chainId(); chainId != "" {
+			n.StateFile = namespaced(cfg.StateFile, chainId)
+			// files set by the network itself are left as they are.
+			if n.Retry.QueueFile == cfg.Retry.QueueFile {
+				n.Retry.QueueFile = namespaced(orDefault(cfg.Retry.QueueFile, DefaultQueueFile), chainId)
+			}
+			if n.Retry.DeadLetterFile == cfg.Retry.DeadLetterFile {
+				n.Retry.DeadLetterFile = namespaced(orDefault(cfg.Retry.DeadLetterFile, DefaultDeadLetterFile), chainId)
+			}
+			if n.History.Backend == "file" && n.History.Path == cfg.History.Path && n.History.Path != "" {
+				n.History.Path = filepath.Join(n.History.Path, chainId)
+			}
+		}
+		cfg.networks = append(cfg.networks, n)
+	}
+	return nil
+}
+
+// NetworkConfigs returns the configuration of every network to monitor, which is the configuration itself without
+// [[networks]].
+func (cfg *Config) NetworkConfigs() []*Config {
+	if len(cfg.Networks) == 0 {
+		return []*Config{cfg}
+	}
+	return cfg.networks
+}
+
+// chainId is the chain id of the network, as set by chain_id or [network].
+func (cfg *Config) chainId() string {
+	if cfg.Network.ChainId != "" {
+		return cfg.Network.ChainId
+	}
+	return cfg.ChainId
+}
+
+// namespaced inserts the chain id before the extension of a file name (ex. .metisian-state.andromeda.json).
+func namespaced(path, chainId string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + chainId + ext
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// readConfig reads a local configuration file, or fetches a remote one.
+func readConfig(filePath, token string) ([]byte, error) {
 	if strings.HasPrefix(filePath, "http://") || strings.HasPrefix(filePath, "https://") {
 		contents, err := util.FetchRemoteFile(filePath, token)
 		if err != nil || len(contents) < 1 {
 			return nil, errors.New(fmt.Sprintf("Didn't find any files. check if there exists content - %v", err))
 		}
-		err = toml.Unmarshal([]byte(contents[0]), cfg)
-		if err != nil {
-			return nil, err
+		return []byte(contents[0]), nil
+	}
+	//#nosec -- variable specified on command line
+	return os.ReadFile(filePath)
+}
+
+// validate checks the settings NewSupervisor and Reload can't work without, every problem found is returned.
+func (cfg *Config) validate() error {
+	if len(cfg.Networks) > 0 {
+		return cfg.validateNetworks()
+	}
+	var errs []error
+	if cfg.EnableDash {
+		if _, err := url.Parse(cfg.Listen); err != nil || cfg.Listen == "" {
+			errs = append(errs, fmt.Errorf("the listen URL %s does not appear to be valid", cfg.Listen))
 		}
-	} else {
-		f, e := os.OpenFile(filePath, os.O_RDONLY, 0600)
-		if e != nil {
-			return nil, e
+		if err := cfg.Dashboard.Validate(); err != nil {
+			errs = append(errs, err)
 		}
-		i, e := f.Stat()
-		if e != nil {
-			_ = f.Close()
-			return nil, e
+	}
+
+	if cfg.EnablePrometheus && cfg.PrometheusListen == "" {
+		errs = append(errs, errors.New("prometheus_listen must be set when enable_prometheus is true"))
+	}
+
+	if cfg.Telegram.Commands && (cfg.Telegram.ApiKey == "" || cfg.Telegram.Channel == "") {
+		errs = append(errs, errors.New("telegram commands need the top level [telegram] api_key and channel"))
+	}
+
+	for _, p := range cfg.Escalations {
+		if err := p.validate(); err != nil {
+			errs = append(errs, err)
 		}
-		b := make([]byte, int(i.Size()))
-		_, e = f.Read(b)
-		_ = f.Close()
-		if e != nil {
-			return nil, e
+	}
+
+	if cfg.NodeDownMin < 3 {
+		errs = append(errs, errors.New("setting 'node_down_alert_minutes' to less than three minutes might result in false alarms"))
+	}
+
+	if _, err := cfg.network(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// validateNetworks checks the settings the [[networks]] can't share, and every network on its own.
+func (cfg *Config) validateNetworks() error {
+	errs := cfg.sharedErrors()
+	for i, n := range cfg.networks {
+		err := n.validate()
+		if err == nil {
+			continue
 		}
-		e = toml.Unmarshal(b, cfg)
-		if e != nil {
-			return nil, e
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			errs = append(errs, fmt.Errorf("%s: %w", networkName(i, n), e))
 		}
 	}
+	return errors.Join(errs...)
+}
 
-	cfg.StateFile = stateFilePath
+// sharedErrors lists the problems between the [[networks]], each network is validated on its own.
+func (cfg *Config) sharedErrors() []error {
+	var errs []error
+	if len(cfg.Sequencers) > 0 || len(cfg.NodeInfos) > 0 {
+		errs = append(errs, errors.New("with [[networks]], the sequencers and node_infos belong in each network"))
+	}
+	if len(cfg.networks) != len(cfg.Networks) {
+		// only LoadConfig resolves the networks.
+		return append(errs, errors.New("the [[networks]] haven't been loaded"))
+	}
+	chainIds := make(map[string]bool)
+	bots := make(map[string]string)
+	for i, n := range cfg.networks {
+		chainId := n.chainId()
+		if chainId != "" && chainIds[chainId] {
+			errs = append(errs, fmt.Errorf("network %s is defined more than once", chainId))
+		}
+		chainIds[chainId] = true
+		if n.Telegram.Commands {
+			// telegram only lets one client receive the updates of a bot.
+			if other, ok := bots[n.Telegram.ApiKey]; ok {
+				errs = append(errs, fmt.Errorf("%s: the telegram bot is already answering commands for %s", networkName(i, n), other))
+			}
+			bots[n.Telegram.ApiKey] = networkName(i, n)
+		}
+	}
+	return errs
+}
 
-	return cfg, nil
+// networkName names a [[networks]] table in errors, by its chain id when it has one.
+func networkName(i int, n *Config) string {
+	if chainId := n.chainId(); chainId != "" {
+		return "network " + chainId
+	}
+	return fmt.Sprintf("[[networks]] %d", i+1)
+}
+
+// network resolves the network to follow, from the built-in networks and the [network] section.
+func (cfg *Config) network() (NetworkConfig, error) {
+	n := cfg.Network
+	switch {
+	case n.ChainId == "":
+		n.ChainId = cfg.ChainId
+	case cfg.ChainId != "" && cfg.ChainId != n.ChainId:
+		return n, fmt.Errorf("chain_id %s and [network] chain_id %s differ", cfg.ChainId, n.ChainId)
+	}
+	if n.ChainId == "" {
+		return n, fmt.Errorf("chain_id is missing, set either %s, %s or a [network]", MAINNET_CHAIN_ID, SEPOLIA_CHAIN_ID)
+	}
+
+	if builtin, ok := builtinNetworks[n.ChainId]; ok {
+		if len(n.SequencerSetUrls) == 0 {
+			n.SequencerSetUrls = builtin.SequencerSetUrls
+		}
+		if len(n.L2RpcUrls) == 0 {
+			n.L2RpcUrls = builtin.L2RpcUrls
+		}
+		if n.BlockTimeSeconds == 0 {
+			n.BlockTimeSeconds = builtin.BlockTimeSeconds
+		}
+	}
+	if len(n.SequencerSetUrls) == 0 || len(n.L2RpcUrls) == 0 {
+		return n, fmt.Errorf("%s isn't a built-in network (%s or %s), [network] needs sequencer_set_urls and l2_rpc_urls",
+			n.ChainId, MAINNET_CHAIN_ID, SEPOLIA_CHAIN_ID)
+	}
+	if n.BlockTimeSeconds < 0 {
+		return n, errors.New("[network] block_time_seconds can't be negative")
+	}
+	return n, nil
 }
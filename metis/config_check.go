@@ -0,0 +1,289 @@
+package metis
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// severities are the severities an alert may have, as understood by pagerduty.
+var severities = []string{"critical", "error", "warning", "info"}
+
+var addressRex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// sharedKeys are the settings of the process, which a [[networks]] table can't change.
+var sharedKeys = []string{"enable_dashboard", "listen_port", "hide_logs", "dashboard", "enable_prometheus", "prometheus_listen"}
+
+// CheckConfig reads a configuration like LoadConfig, and reports every problem found in it, beyond the ones
+// NewSupervisor can't work without. The error is only set when the configuration can't be read or parsed at all.
+func CheckConfig(filePath, token string) ([]string, error) {
+	b, err := readConfig(filePath, token)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := make([]string, 0)
+	cfg := &Config{}
+	decoder := toml.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(cfg)
+	var (
+		strict    *toml.StrictMissingError
+		decodeErr *toml.DecodeError
+	)
+	switch {
+	case errors.As(err, &strict):
+		// the known keys are decoded all the same.
+		for _, e := range strict.Errors {
+			row, _ := e.Position()
+			problems = append(problems, fmt.Sprintf("line %d: unknown key %s", row, strings.Join(e.Key(), ".")))
+		}
+	case errors.As(err, &decodeErr):
+		row, _ := decodeErr.Position()
+		return nil, fmt.Errorf("line %d: %w", row, err)
+	case err != nil:
+		return nil, err
+	}
+
+	if err = cfg.loadNetworks(b); err != nil {
+		return nil, err
+	}
+	if err = cfg.resolveSecrets(); err != nil {
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			problems = append(problems, e.Error())
+		}
+	}
+	for i, table := range cfg.Networks {
+		// the tables were decoded into maps, which take any key.
+		nb, err := toml.Marshal(table)
+		if err != nil {
+			return nil, fmt.Errorf("[[networks]] %d: %w", i+1, err)
+		}
+		decoder = toml.NewDecoder(bytes.NewReader(nb))
+		decoder.DisallowUnknownFields()
+		if errors.As(decoder.Decode(&Config{}), &strict) {
+			for _, e := range strict.Errors {
+				problems = append(problems, fmt.Sprintf("%s: unknown key %s", networkName(i, cfg.networks[i]), strings.Join(e.Key(), ".")))
+			}
+		}
+	}
+	return append(problems, cfg.check()...), nil
+}
+
+// checkNetworks lists the problems between the [[networks]], followed by the problems of each network.
+func (cfg *Config) checkNetworks() []string {
+	problems := make([]string, 0)
+	for _, err := range cfg.sharedErrors() {
+		problems = append(problems, err.Error())
+	}
+	for i, n := range cfg.networks {
+		name := networkName(i, n)
+		for _, key := range sharedKeys {
+			if _, ok := cfg.Networks[i][key]; ok {
+				problems = append(problems, fmt.Sprintf("%s: %s is shared by every network, it is only read at the top level", name, key))
+			}
+		}
+		for _, problem := range n.check() {
+			problems = append(problems, fmt.Sprintf("%s: %s", name, problem))
+		}
+	}
+	return problems
+}
+
+// check lists the problems of a configuration, starting with the ones reported by validate.
+func (cfg *Config) check() []string {
+	if len(cfg.Networks) > 0 {
+		return cfg.checkNetworks()
+	}
+	problems := make([]string, 0)
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if err := cfg.validate(); err != nil {
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			add("%v", e)
+		}
+	}
+
+	network := cfg.Network
+	for _, urls := range [][]string{network.SequencerSetUrls, network.L2RpcUrls} {
+		for _, u := range urls {
+			if parsed, err := url.ParseRequestURI(u); err != nil || parsed.Host == "" {
+				add("[network] %q isn't a valid url", u)
+			}
+		}
+	}
+
+	if cfg.StalledAlerts && cfg.Stalled <= 0 {
+		add("stalled_enabled needs stalled_minutes")
+	}
+	checkSeverity := func(where, value string) {
+		if value != "" && !contains(severities, value) {
+			add("%s: unknown severity %q, expected one of %s", where, value, strings.Join(severities, ", "))
+		}
+	}
+	checkSeverity("node_down_alert_severity", cfg.NodeDownSeverity)
+	checkSeverity("[pagerduty] default_severity", cfg.Pagerduty.DefaultSeverity)
+	problems = append(problems, checkDestinations("", cfg.Destinations)...)
+
+	if len(cfg.Sequencers) == 0 {
+		add("no [[sequencers]] to watch")
+	}
+	names := make(map[string]bool)
+	addresses := make(map[string]string)
+	for i, seq := range cfg.Sequencers {
+		where := fmt.Sprintf("sequencer %q", seq.Name)
+		switch {
+		case seq.Name == "":
+			where = fmt.Sprintf("sequencer %d", i+1)
+			add("%s has no name", where)
+		case seq.Name == MetisianName:
+			add("%s: the name is used by metisian's own alerts", where)
+		case names[seq.Name]:
+			add("%s is defined more than once", where)
+		}
+		names[seq.Name] = true
+
+		address := strings.ToLower(seq.Address)
+		if !addressRex.MatchString(seq.Address) {
+			add("%s: address %q isn't a 0x prefixed 20 byte hex address", where, seq.Address)
+		} else if other := addresses[address]; other != "" {
+			add("%s: address is also used by %s", where, other)
+		}
+		addresses[address] = where
+
+		checkSeverity(where+": alerts.consecutive_priority", seq.Alerts.ConsecutivePriority)
+		if seq.UseParent || seq.Alerts.UseParent {
+			if overrides := configured(seq.Alerts.Destinations); len(overrides) > 0 {
+				add("%s: use_parent replaces its own alerts.%s", where, strings.Join(overrides, ", alerts."))
+			}
+			continue
+		}
+		if len(configured(seq.Alerts.Destinations)) == 0 {
+			add("%s: has neither use_parent nor destinations of its own, its alerts aren't sent", where)
+		}
+		checkSeverity(where+": alerts.pagerduty.default_severity", seq.Alerts.Pagerduty.DefaultSeverity)
+		for _, problem := range checkDestinations("alerts.", seq.Alerts.Destinations) {
+			add("%s: %s", where, problem)
+		}
+	}
+
+	if len(cfg.NodeInfos) == 0 {
+		add("no [[node_infos]] to follow the chain through")
+	}
+	rpcUrls := make(map[string]bool)
+	for i, node := range cfg.NodeInfos {
+		where := fmt.Sprintf("node %d", i+1)
+		if node.RpcURL == "" {
+			add("%s: rpc_url is missing", where)
+		} else if err := checkNodeUrl(node.RpcURL); err != nil {
+			add("%s: rpc_url: %v", where, err)
+		} else if rpcUrls[node.RpcURL] {
+			add("%s: rpc_url %s is defined more than once", where, node.RpcURL)
+		}
+		rpcUrls[node.RpcURL] = true
+		if node.WsURL != "" {
+			if err := checkNodeUrl(node.WsURL); err != nil {
+				add("%s: ws_url: %v", where, err)
+			}
+		}
+		if node.ApiURL != "" {
+			if _, err := url.ParseRequestURI(node.ApiURL); err != nil {
+				add("%s: api_url: %v", where, err)
+			}
+		}
+	}
+
+	for i, p := range cfg.Escalations {
+		where := "escalation " + p.Name
+		if p.Name == "" {
+			where = fmt.Sprintf("escalation %d", i+1)
+		}
+		for _, severity := range p.Severities {
+			checkSeverity(where, severity)
+		}
+		for _, name := range p.Sequencers {
+			if !names[name] {
+				add("%s: unknown sequencer %q", where, name)
+			}
+		}
+	}
+
+	now := time.Now()
+	for i, s := range cfg.Silences {
+		s := *s
+		if err := s.init(now); err != nil {
+			add("silence %d: %v", i+1, err)
+		} else if s.expired(now) {
+			add("silence %d has already expired", i+1)
+		}
+		if s.Sequencer != "" && !names[s.Sequencer] {
+			add("silence %d: unknown sequencer %q", i+1, s.Sequencer)
+		}
+	}
+
+	switch cfg.History.Backend {
+	case "":
+	case "mysql":
+		if cfg.History.Dsn == "" {
+			add("[history] the mysql backend needs a dsn")
+		}
+	case "file":
+		if cfg.History.Path == "" {
+			add("[history] the file backend needs a path")
+		}
+		if cfg.History.MaxFileMB < 0 {
+			add("[history] max_file_mb can't be negative")
+		}
+	default:
+		add("[history] unknown backend %q, expected mysql or file", cfg.History.Backend)
+	}
+	return problems
+}
+
+// checkDestinations lists what the enabled destinations are missing, section prefixes their name.
+func checkDestinations(section string, d Destinations) []string {
+	problems := make([]string, 0)
+	msg := &alertMsg{dest: d}
+	for _, n := range registeredNotifiers() {
+		checker, ok := n.(configChecker)
+		if !ok || !n.Enabled(msg) {
+			continue
+		}
+		for _, problem := range checker.checkConfig(d) {
+			problems = append(problems, fmt.Sprintf("[%s%s] %s", section, n.Name(), problem))
+		}
+	}
+	return problems
+}
+
+// configured lists the destinations which have any setting.
+func configured(d Destinations) []string {
+	names := make([]string, 0)
+	v := reflect.ValueOf(d)
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Field(i).IsZero() {
+			names = append(names, v.Type().Field(i).Tag.Get("toml"))
+		}
+	}
+	return names
+}
+
+// checkNodeUrl checks the url of a node the way NewMetisClient uses it.
+func checkNodeUrl(remote string) error {
+	endpoint, err := NewWsUrl(remote)
+	if err != nil {
+		return err
+	}
+	if endpoint.Host == "" {
+		return fmt.Errorf("%s has no host", remote)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package metis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultNodeLagBlocks is used when node_lag_alert_blocks isn't set.
+const defaultNodeLagBlocks = 10
+
+// checkConsensus compares the healthy nodes with each other: a node more than node_lag_alert_blocks behind the
+// highest one raises an alert, and so do nodes returning different hashes for the same height. When a majority of
+// the nodes agree on the hash, the others are marked as forked so that the pool doesn't use them.
+func (c *MetisianClient) checkConsensus(ctx context.Context) {
+	nodes, t := c.nodes(), c.thresholds()
+	healthy := make([]NodeInfo, 0, len(nodes))
+	heights := make(map[string]int64)
+	var top, common int64
+	for _, node := range nodes {
+		st := node.health.snapshot()
+		if st.down || st.height == 0 {
+			continue
+		}
+		healthy = append(healthy, node)
+		heights[node.RpcURL] = st.height
+		if st.height > top {
+			top = st.height
+		}
+		if common == 0 || st.height < common {
+			common = st.height
+		}
+	}
+	// lagging nodes, a node which is down or doesn't report a height is alerted by the probe instead, and a single
+	// healthy node has no peer to lag behind.
+	for _, node := range nodes {
+		id := node.RpcURL + "lag"
+		height, ok := heights[node.RpcURL]
+		lagging := ok && len(healthy) >= 2 && top-height > int64(t.nodeLagBlocks)
+		if lagging && node.AlertIfDown {
+			c.raiseConsensusAlarm(id, fmt.Sprintf("Severity: %s\nRPC node %s lags more than %d blocks behind its peers (%d < %d)",
+				t.nodeDownSeverity, node.RpcURL, t.nodeLagBlocks, height, top), t.nodeDownSeverity)
+		} else if !lagging {
+			c.clearConsensusAlarm(id)
+		}
+	}
+	if len(healthy) < 2 {
+		return
+	}
+
+	// block hashes at the highest height every node has
+	hashes := make(map[string][]string)
+	var (
+		wg  sync.WaitGroup
+		mux sync.Mutex
+	)
+	for _, node := range healthy {
+		wg.Add(1)
+		go func(node NodeInfo) {
+			defer wg.Done()
+			qCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			hash, err := getBlockHash(qCtx, node.RpcURL, common)
+			if err != nil {
+				// the probe reports unreachable nodes, an older block may just have been pruned.
+				return
+			}
+			mux.Lock()
+			hashes[hash] = append(hashes[hash], node.RpcURL)
+			mux.Unlock()
+		}(node)
+	}
+	wg.Wait()
+
+	forked := make(map[string]bool)
+	if len(hashes) > 1 {
+		groups := make([][]string, 0, len(hashes))
+		var desc []string
+		for hash, nodes := range hashes {
+			sort.Strings(nodes)
+			groups = append(groups, nodes)
+			desc = append(desc, fmt.Sprintf("%s: %s", hash, strings.Join(nodes, ", ")))
+		}
+		sort.Strings(desc)
+		sort.Slice(groups, func(i, j int) bool { return len(groups[i]) > len(groups[j]) })
+		if len(groups[0]) > len(groups[1]) {
+			for _, minority := range groups[1:] {
+				for _, u := range minority {
+					forked[u] = true
+				}
+			}
+		}
+		c.raiseConsensusAlarm("fork", fmt.Sprintf("RPC nodes report different block hashes at height %d\n%s",
+			common, strings.Join(desc, "\n")), "critical")
+	} else if len(hashes) == 1 {
+		c.clearConsensusAlarm("fork")
+	}
+
+	for _, node := range nodes {
+		node.health.setForked(forked[node.RpcURL])
+	}
+}
+
+// raiseConsensusAlarm alerts once per id, the message is kept so that it can be resolved later.
+func (c *MetisianClient) raiseConsensusAlarm(id, message, severity string) {
+	if c.consensusAlarms[id] != "" {
+		return
+	}
+	c.consensusAlarms[id] = message
+	c.alert(MetisianName, message, severity, false, false, &id)
+}
+
+func (c *MetisianClient) clearConsensusAlarm(id string) {
+	message := c.consensusAlarms[id]
+	if message == "" {
+		return
+	}
+	delete(c.consensusAlarms, id)
+	c.alert(MetisianName, message, "info", true, false, &id)
+}
+
+// getBlockHash returns the hash of the block at height, as seen by the node.
+func getBlockHash(ctx context.Context, u string, height int64) (string, error) {
+	b, err := rpcGet(ctx, u, fmt.Sprintf("/blockchain?minHeight=%d&maxHeight=%d", height, height))
+	if err != nil {
+		return "", err
+	}
+	var chain struct {
+		Result struct {
+			BlockMetas []struct {
+				BlockId struct {
+					Hash string `json:"hash"`
+				} `json:"block_id"`
+			} `json:"block_metas"`
+		} `json:"result"`
+	}
+	if err = json.Unmarshal(b, &chain); err != nil {
+		return "", err
+	}
+	if len(chain.Result.BlockMetas) == 0 || chain.Result.BlockMetas[0].BlockId.Hash == "" {
+		return "", errors.New("block not found")
+	}
+	return chain.Result.BlockMetas[0].BlockId.Hash, nil
+}
@@ -0,0 +1,144 @@
+package metis
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSchedule is a parsed five field cron expression: minute, hour, day of month, month and day of week. Fields
+// accept *, single values, ranges (1-5), lists (1,3,5) and steps (*/15, 0-30/10). It is evaluated in UTC.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64 // bitsets of the allowed values
+	domStar, dowStar              bool
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+	var bits [5]uint64
+	for i, f := range fields {
+		b, err := parseCronField(f, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("cron %s field %q: %w", cronFields[i].name, f, err)
+		}
+		bits[i] = b
+	}
+	// sunday is both 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &cronSchedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		stepped := false
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s < 1 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			step, stepped = s, true
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			if hi, err = strconv.Atoi(bounds[1]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", bounds[1])
+			}
+		default:
+			v, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = v, v
+			if stepped {
+				// like cron, a/n runs from a to the end of the range.
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%d-%d is outside of %d-%d", lo, hi, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// matches reports whether the schedule fires in the minute of t.
+func (cs *cronSchedule) matches(t time.Time) bool {
+	t = t.UTC()
+	return cs.minute&(1<<uint(t.Minute())) != 0 && cs.hour&(1<<uint(t.Hour())) != 0 && cs.dayMatches(t)
+}
+
+// dayMatches reports whether the schedule fires on the day of t, which is in UTC.
+func (cs *cronSchedule) dayMatches(t time.Time) bool {
+	if cs.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := cs.dom&(1<<uint(t.Day())) != 0
+	dowMatch := cs.dow&(1<<uint(t.Weekday())) != 0
+	// like cron, when both days are restricted either one is enough.
+	if !cs.domStar && !cs.dowStar {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// within reports whether t falls in a window of length d started by the schedule. It looks for the latest start,
+// skipping a whole day or hour at once when the schedule doesn't fire in it.
+func (cs *cronSchedule) within(t time.Time, d time.Duration) bool {
+	now := t.UTC().Truncate(time.Minute)
+	earliest := now.Add(-d)
+	for at := now; at.After(earliest); {
+		switch {
+		case !cs.dayMatches(at):
+			at = time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC).Add(-time.Minute)
+		case cs.hour&(1<<uint(at.Hour())) == 0:
+			at = at.Truncate(time.Hour).Add(-time.Minute)
+		default:
+			// the minutes of this hour up to at
+			if m := cs.minute & (1<<uint(at.Minute()+1) - 1); m != 0 {
+				return at.Truncate(time.Hour).Add(time.Duration(bits.Len64(m)-1) * time.Minute).After(earliest)
+			}
+			at = at.Truncate(time.Hour).Add(-time.Minute)
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package metis
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+		minute  uint64
+		dow     uint64
+	}{
+		{expr: "* * * * *", minute: 1<<60 - 1, dow: 1<<8 - 1},
+		{expr: "30 2 * * 0", minute: 1 << 30, dow: 1},
+		{expr: "0 0 * * 7", minute: 1, dow: 1<<7 | 1},
+		{expr: "0,15,45 * * * 1-5", minute: 1 | 1<<15 | 1<<45, dow: 0b111110},
+		{expr: "*/20 * * * *", minute: 1 | 1<<20 | 1<<40, dow: 1<<8 - 1},
+		{expr: "10-30/10 * * * *", minute: 1<<10 | 1<<20 | 1<<30, dow: 1<<8 - 1},
+		// like cron, a/n runs from a to the end of the range.
+		{expr: "50/5 * * * *", minute: 1<<50 | 1<<55, dow: 1<<8 - 1},
+		{expr: "* * * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "* 24 * * *", wantErr: true},
+		{expr: "* * 0 * *", wantErr: true},
+		{expr: "5-1 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "a * * * *", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			cs, err := parseCron(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCron(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if cs.minute != tt.minute {
+				t.Errorf("minute = %b, want %b", cs.minute, tt.minute)
+			}
+			if cs.dow != tt.dow {
+				t.Errorf("day of week = %b, want %b", cs.dow, tt.dow)
+			}
+		})
+	}
+}
+
+func TestCronWithin(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		name   string
+		expr   string
+		t      time.Time
+		window time.Duration
+		want   bool
+	}{
+		// 2024-06-02 is a sunday
+		{"at the start", "30 2 * * 0", at("2024-06-02T02:30:00Z"), time.Hour, true},
+		{"before the start", "30 2 * * 0", at("2024-06-02T02:29:59Z"), time.Hour, false},
+		{"last minute of the window", "30 2 * * 0", at("2024-06-02T03:29:59Z"), time.Hour, true},
+		{"window ended", "30 2 * * 0", at("2024-06-02T03:30:00Z"), time.Hour, false},
+		{"other weekday", "30 2 * * 0", at("2024-06-03T02:45:00Z"), time.Hour, false},
+		{"across midnight", "0 23 * * 6", at("2024-06-02T00:30:00Z"), 2 * time.Hour, true},
+		{"across a month", "0 22 31 5 *", at("2024-06-01T01:00:00Z"), 4 * time.Hour, true},
+		{"week long window", "0 0 * * 1", at("2024-06-09T23:59:00Z"), 7 * 24 * time.Hour, true},
+		{"week long window ended", "0 0 * * 1", at("2024-06-10T00:00:00Z").Add(-time.Nanosecond), 6 * 24 * time.Hour, false},
+		{"latest start of several", "*/15 * * * *", at("2024-06-02T10:39:00Z"), 10 * time.Minute, true},
+		{"between starts", "*/15 * * * *", at("2024-06-02T10:40:00Z"), 10 * time.Minute, false},
+		{"day of month or week", "0 12 1 * 3", at("2024-06-05T12:10:00Z"), 30 * time.Minute, true},
+		{"empty window", "* * * * *", at("2024-06-02T10:40:00Z"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := parseCron(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := cs.within(tt.t, tt.window); got != tt.want {
+				t.Errorf("within(%s, %s) = %v, want %v", tt.t, tt.window, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCronWithinMatchesEveryMinute checks within against looking at every minute of the window.
+func TestCronWithinMatchesEveryMinute(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, expr := range []string{"30 2 * * 0", "*/7 9-17 * * 1-5", "0 0 1,15 * *", "0 12 13 * 5", "59 23 31 12 *"} {
+		cs, err := parseCron(expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 500; i++ {
+			now := base.Add(time.Duration(rng.Int63n(int64(366 * 24 * time.Hour))))
+			window := time.Duration(rng.Int63n(int64(7*24*time.Hour))) + time.Minute
+			want := false
+			start := now.Truncate(time.Minute)
+			for offset := time.Duration(0); offset < window; offset += time.Minute {
+				if cs.matches(start.Add(-offset)) {
+					want = true
+					break
+				}
+			}
+			if got := cs.within(now, window); got != want {
+				t.Fatalf("%q within(%s, %s) = %v, want %v", expr, now, window, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package dash
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Config secures the dashboard. Without users every request is an operator, as before authentication existed.
+type Config struct {
+	// TLSCert and TLSKey serve the dashboard over https, the files are read again on SIGHUP.
+	TLSCert string `toml:"tls_cert"`
+	TLSKey  string `toml:"tls_key"`
+	// CorsOrigins are the origins allowed to call the dashboard from a browser, "*" allows any and is the default.
+	CorsOrigins []string `toml:"cors_origins"`
+	// Anonymous is the role of requests without credentials once users are set: "public" (the default) or "none".
+	Anonymous string `toml:"anonymous"`
+	Users     []User `toml:"users"`
+	// LogLevel is the lowest level of the logs shown on the dashboard, info by default.
+	LogLevel string `toml:"log_level"`
+}
+
+// User authenticates with basic auth, using name and password, or with a bearer token. The password may be a bcrypt
+// hash.
+type User struct {
+	Name     string `toml:"name"`
+	Password string `toml:"password" secret:"true"`
+	Token    string `toml:"token" secret:"true"`
+	// Role is either public or operator.
+	Role string `toml:"role"`
+}
+
+// Role is what a request may see. The public view is read-only and has no logs, operators also see the logs and may
+// change silences or replay notifications.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RolePublic
+	RoleOperator
+)
+
+func parseRole(s string) (Role, error) {
+	switch s {
+	case "none":
+		return RoleNone, nil
+	case "public":
+		return RolePublic, nil
+	case "operator":
+		return RoleOperator, nil
+	default:
+		return RoleNone, fmt.Errorf("unknown dashboard role %q, expected public or operator", s)
+	}
+}
+
+// Access is what a route needs.
+type Access int
+
+const (
+	// ReadOnly routes are public for GET requests, other methods need an operator.
+	ReadOnly Access = iota
+	// Public routes are public whatever the method, for read-only apis queried over POST such as GraphQL.
+	Public
+	// Operator routes always need an operator.
+	Operator
+)
+
+// Validate checks the users and roles.
+func (cfg Config) Validate() error {
+	if (cfg.TLSCert == "") != (cfg.TLSKey == "") {
+		return errors.New("dashboard tls needs both tls_cert and tls_key")
+	}
+	if cfg.Anonymous != "" && cfg.Anonymous != "public" && cfg.Anonymous != "none" {
+		return fmt.Errorf("dashboard anonymous must be public or none, not %q", cfg.Anonymous)
+	}
+	if _, err := cfg.MinLogLevel(); err != nil {
+		return err
+	}
+	for i, u := range cfg.Users {
+		if role, err := parseRole(u.Role); err != nil || role == RoleNone {
+			return fmt.Errorf("dashboard user %d: role must be public or operator", i+1)
+		}
+		if u.Token == "" && (u.Name == "" || u.Password == "") {
+			return fmt.Errorf("dashboard user %d: needs either a name and a password or a token", i+1)
+		}
+	}
+	for _, o := range cfg.CorsOrigins {
+		if o == "*" {
+			continue
+		}
+		if u, err := url.Parse(o); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("dashboard cors origin %q must be a scheme and host, like https://example.com", o)
+		}
+	}
+	return nil
+}
+
+// MinLogLevel parses log_level.
+func (cfg Config) MinLogLevel() (zerolog.Level, error) {
+	if cfg.LogLevel == "" {
+		return zerolog.InfoLevel, nil
+	}
+	l, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil || l == zerolog.NoLevel {
+		return zerolog.InfoLevel, fmt.Errorf("invalid dashboard log_level %q", cfg.LogLevel)
+	}
+	return l, nil
+}
+
+type roleKey struct{}
+
+// RoleOf returns the role of a request served by the dashboard.
+func RoleOf(r *http.Request) Role {
+	return RoleIn(r.Context())
+}
+
+// RoleIn returns the role of the request a context was derived from, for handlers which only get the context.
+func RoleIn(ctx context.Context) Role {
+	role, _ := ctx.Value(roleKey{}).(Role)
+	return role
+}
+
+// guard authenticates requests and sets the cors headers.
+type guard struct {
+	users     []User
+	anonymous Role
+	origins   map[string]bool
+	anyOrigin bool
+	// operators is false when users are set but none is an operator, the operator routes are then refused.
+	operators bool
+}
+
+func newGuard(cfg Config) *guard {
+	g := &guard{users: cfg.Users, anonymous: RoleOperator, origins: make(map[string]bool), operators: len(cfg.Users) == 0}
+	if len(cfg.Users) > 0 {
+		g.anonymous = RolePublic
+		if cfg.Anonymous == "none" {
+			g.anonymous = RoleNone
+		}
+	}
+	for _, u := range cfg.Users {
+		if role, _ := parseRole(u.Role); role == RoleOperator {
+			g.operators = true
+		}
+	}
+	if len(cfg.CorsOrigins) == 0 {
+		g.anyOrigin = true
+	}
+	for _, o := range cfg.CorsOrigins {
+		if o == "*" {
+			g.anyOrigin = true
+		}
+		g.origins[strings.TrimRight(o, "/")] = true
+	}
+	return g
+}
+
+// authenticate returns the role of the request and whether credentials were given, ok is false when they are wrong.
+// Websockets opened from a browser can't set headers, so a token may also be passed as the access_token query
+// parameter of a websocket upgrade. Other requests only take the header, a query string ends up in access logs.
+func (g *guard) authenticate(r *http.Request) (role Role, given, ok bool) {
+	var token string
+	if websocket.IsWebSocketUpgrade(r) {
+		token = r.URL.Query().Get("access_token")
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		token = strings.TrimPrefix(auth, "Bearer ")
+	}
+	name, password, basic := r.BasicAuth()
+	if token == "" && !basic {
+		return g.anonymous, false, true
+	}
+
+	for _, u := range g.users {
+		if token != "" && u.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(u.Token)) == 1 {
+			role, _ = parseRole(u.Role)
+			return role, true, true
+		}
+		if basic && u.Name != "" && u.Name == name && checkPassword(u.Password, password) {
+			role, _ = parseRole(u.Role)
+			return role, true, true
+		}
+	}
+	return RoleNone, true, false
+}
+
+func checkPassword(want, have string) bool {
+	if strings.HasPrefix(want, "$2a$") || strings.HasPrefix(want, "$2b$") || strings.HasPrefix(want, "$2y$") {
+		return bcrypt.CompareHashAndPassword([]byte(want), []byte(have)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(want), []byte(have)) == 1
+}
+
+// allowedOrigin reports whether a browser on origin may call the dashboard.
+func (g *guard) allowedOrigin(origin string) bool {
+	return g.anyOrigin || g.origins[strings.TrimRight(origin, "/")]
+}
+
+// checkOrigin accepts websockets from the allowed origins, and from the dashboard itself.
+func (g *guard) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || g.allowedOrigin(origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func (g *guard) wrap(handler http.Handler, access Access) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if origin := request.Header.Get("Origin"); origin != "" && g.allowedOrigin(origin) {
+			writer.Header().Add("Vary", "Origin")
+			if g.anyOrigin {
+				writer.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				writer.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			if request.Method == http.MethodOptions {
+				writer.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		role, given, ok := g.authenticate(request)
+		need := RolePublic
+		if access == Operator || (access == ReadOnly && request.Method != http.MethodGet && request.Method != http.MethodHead) {
+			need = RoleOperator
+		}
+		switch {
+		case need == RoleOperator && !g.operators:
+			http.Error(writer, "forbidden, the dashboard has no operator user", http.StatusForbidden)
+			return
+		case !ok || (role < need && !given):
+			writer.Header().Set("WWW-Authenticate", `Basic realm="metisian"`)
+			http.Error(writer, "unauthorized", http.StatusUnauthorized)
+			return
+		case role < need:
+			http.Error(writer, "forbidden", http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), roleKey{}, role)))
+	})
+}
+
+// activeGuard is the guard of the running dashboard, CheckOrigin uses it.
+var activeGuard = newGuard(Config{})
+
+// CheckOrigin is the websocket origin check of the dashboard, for upgraders outside of this package.
+func CheckOrigin(r *http.Request) bool {
+	return activeGuard.checkOrigin(r)
+}
@@ -1,9 +1,11 @@
 package dash
 
 import (
+	"crypto/tls"
 	"embed"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/b-harvest/metisian/log"
 	"io/fs"
 	"net/http"
@@ -19,18 +21,45 @@ import (
 var (
 	Content embed.FS
 	rootDir fs.FS
-	rex     = regexp.MustCompile(`\W(https?|tcp|wss?)://.+\w`)
+	// rex matches urls, rpc urls often hold api keys.
+	rex = regexp.MustCompile(`\b(https?|tcp|wss?)://[^\s"'<>()]+`)
 )
 
 const logLength = 256
 
-func Serve(port string, updates chan *SequencerStatus, logs chan LogMessage, hideLogs bool) {
+type route struct {
+	handler http.Handler
+	access  Access
+}
+
+var routes = make(map[string]route)
+
+// Handle registers an additional handler served by the dashboard, it must be called before Serve.
+func Handle(pattern string, handler http.Handler, access Access) {
+	routes[pattern] = route{handler: handler, access: access}
+}
+
+// castMessage is sent to the /ws clients, logs are only sent to operators.
+type castMessage struct {
+	log  bool
+	data []byte
+}
+
+func Serve(port string, updates chan *SequencerStatus, logs chan LogMessage, hideLogs bool, cfg Config) {
 	var err error
 	rootDir, err = fs.Sub(Content, "static")
 	if err != nil {
 		log.Fatal(err)
 	}
 	var cast broadcast.Broadcaster
+	g := newGuard(cfg)
+	activeGuard = g
+	switch {
+	case len(cfg.Users) == 0:
+		log.Warn("🔓 the dashboard has no users, anyone reaching it may change silences and replay notifications")
+	case !g.operators:
+		log.Warn("🔒 no dashboard user is an operator, silences and dead letters can only be changed from config.toml")
+	}
 
 	// cache the json .... don't serialize on-demand
 	logCache, statusCache := []byte{'[', ']'}, []byte{'{', '}'}
@@ -51,23 +80,32 @@ func Serve(port string, updates chan *SequencerStatus, logs chan LogMessage, hid
 			select {
 			case <-tick.C:
 				if update {
-					_ = cast.Send(statusCache)
+					_ = cast.Send(castMessage{data: statusCache})
 					update = false
 				}
 
 			case u := <-updates:
 				// try to catch any accidental rpc endpoint leaks
-				if hideLogs && rex.MatchString(u.LastError) {
-					rex.ReplaceAllString(u.LastError, "-redacted-")
+				if hideLogs {
+					u.LastError = Redact(u.LastError)
 				}
 				statusMux.Lock() // probably unnecessary
-				status[u.Name] = u
+				// several networks may have a sequencer with the same name.
+				key := u.ChainId + "/" + u.Name
+				if u.MsgType == "removed" {
+					delete(status, key)
+				} else {
+					status[key] = u
+				}
 				result := make([]*SequencerStatus, 0)
 				for k := range status {
 					result = append(result, status[k])
 				}
 				statusMux.Unlock()
 				sort.Slice(result, func(i, j int) bool {
+					if result[i].ChainId != result[j].ChainId {
+						return result[i].ChainId < result[j].ChainId
+					}
 					return sort.StringsAreSorted([]string{result[i].Name, result[j].Name})
 				})
 				j, e := json.Marshal(statusUpdate{
@@ -84,6 +122,8 @@ func Serve(port string, updates chan *SequencerStatus, logs chan LogMessage, hid
 				if hideLogs {
 					continue
 				}
+				// the log pane may be shown on a shared screen, the console keeps the urls.
+				l.Msg = Redact(l.Msg)
 				if len(logSlice) >= logLength {
 					logSlice = append([]LogMessage{l}, logSlice[0:len(logSlice)-1]...)
 				} else {
@@ -98,16 +138,24 @@ func Serve(port string, updates chan *SequencerStatus, logs chan LogMessage, hid
 				if e != nil {
 					continue
 				}
-				_ = cast.Send(j)
+				_ = cast.Send(castMessage{log: true, data: j})
 			}
 		}
 	}()
 
 	var upgrader = websocket.Upgrader{}
-	upgrader.CheckOrigin = func(r *http.Request) bool { return true }
+	upgrader.CheckOrigin = g.checkOrigin
 	upgrader.EnableCompression = true
 
-	http.HandleFunc("/ws", func(writer http.ResponseWriter, request *http.Request) {
+	mux := http.NewServeMux()
+	handle := func(pattern string, access Access, handler http.HandlerFunc) {
+		mux.Handle(pattern, g.wrap(handler, access))
+	}
+
+	// /ws feeds the bundled dashboard with the status of every network and the logs, which the per network GraphQL
+	// api doesn't serve. Other clients should use the sequencerStatus subscription on /graphql.
+	handle("/ws", ReadOnly, func(writer http.ResponseWriter, request *http.Request) {
+		operator := RoleOf(request) == RoleOperator
 		c, err := upgrader.Upgrade(writer, request, nil)
 		if err != nil {
 			return
@@ -116,42 +164,63 @@ func Serve(port string, updates chan *SequencerStatus, logs chan LogMessage, hid
 		sub := cast.Listen()
 		defer sub.Discard()
 		for message := range sub.Channel() {
-			e := c.WriteMessage(websocket.TextMessage, message.([]byte))
+			m := message.(castMessage)
+			if m.log && !operator {
+				continue
+			}
+			e := c.WriteMessage(websocket.TextMessage, m.data)
 			if e != nil {
 				return
 			}
 		}
 	})
 
-	http.HandleFunc("/logsenabled", func(writer http.ResponseWriter, request *http.Request) {
+	handle("/logsenabled", ReadOnly, func(writer http.ResponseWriter, request *http.Request) {
 		writer.Header().Set("Content-Type", "application/json")
-		writer.Header().Set("Access-Control-Allow-Origin", "*")
-		j, _ := json.Marshal(map[string]bool{"enabled": !hideLogs})
+		j, _ := json.Marshal(map[string]bool{"enabled": !hideLogs && RoleOf(request) == RoleOperator})
 		_, _ = writer.Write(j)
 	})
 
-	http.HandleFunc("/logs", func(writer http.ResponseWriter, request *http.Request) {
+	handle("/logs", Operator, func(writer http.ResponseWriter, request *http.Request) {
 		writer.Header().Set("Content-Type", "application/json")
-		writer.Header().Set("Access-Control-Allow-Origin", "*")
 		_, _ = writer.Write(logCache)
 	})
 
-	http.HandleFunc("/state", func(writer http.ResponseWriter, request *http.Request) {
+	handle("/state", ReadOnly, func(writer http.ResponseWriter, request *http.Request) {
 		writer.Header().Set("Content-Type", "application/json")
-		writer.Header().Set("Access-Control-Allow-Origin", "*")
 		_, _ = writer.Write(statusCache)
 	})
 
-	http.Handle("/", &CacheHandler{})
+	for pattern, r := range routes {
+		mux.Handle(pattern, g.wrap(r.handler, r.access))
+	}
+
+	mux.Handle("/", g.wrap(&CacheHandler{}, ReadOnly))
 	server := &http.Server{
 		Addr:              ":" + port,
+		Handler:           mux,
 		ReadHeaderTimeout: 3 * time.Second,
 	}
-	err = server.ListenAndServe()
+	if cfg.TLSCert != "" {
+		certs, e := newCertReloader(cfg.TLSCert, cfg.TLSKey)
+		if e != nil {
+			log.Fatal(fmt.Errorf("loading the dashboard certificate: %w", e))
+		}
+		go certs.watch()
+		server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: certs.getCertificate}
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
 	cast.Discard()
 	log.Fatal(errors.New("metisian dashboard server failed" + err.Error()))
 }
 
+// Redact replaces the urls of s, rpc urls often hold api keys.
+func Redact(s string) string {
+	return rex.ReplaceAllString(s, "-redacted-")
+}
+
 // CacheHandler implements the Handler interface with a Cache-Control set on responses
 type CacheHandler struct{}
 
@@ -0,0 +1,58 @@
+package dash
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// certReloader serves the certificate from files which are read again on SIGHUP, so that a renewed certificate is
+// used without a restart.
+type certReloader struct {
+	certFile, keyFile string
+
+	mux  sync.RWMutex
+	cert *tls.Certificate
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mux.Lock()
+	r.cert = &cert
+	r.mux.Unlock()
+	return nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+	return r.cert, nil
+}
+
+// watch reloads the certificate on SIGHUP, a certificate which can't be loaded keeps the previous one.
+func (r *certReloader) watch() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		if err := r.reload(); err != nil {
+			log.Warn(fmt.Sprintf("🔒 keeping the previous dashboard certificate: %v", err))
+			continue
+		}
+		log.Info("🔒 reloaded the dashboard certificate")
+	}
+}
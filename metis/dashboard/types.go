@@ -2,6 +2,7 @@ package dash
 
 type SequencerStatus struct {
 	MsgType      string `json:"msgType"`
+	ChainId      string `json:"chain_id"`
 	Name         string `json:"name"`
 	Address      string `json:"address"`
 	Jailed       bool   `json:"jailed"`
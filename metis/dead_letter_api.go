@@ -0,0 +1,47 @@
+package metis
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// deadLetterHandler serves the dead-letter api on the dashboard:
+//
+//	GET  /dead-letters         lists the notifications which couldn't be delivered
+//	POST /dead-letters/replay  queues them again, the optional body {"ids": [...]} selects which ones
+func (c *MetisianClient) deadLetterHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+
+		action := strings.Trim(strings.TrimPrefix(request.URL.Path, "/dead-letters"), "/")
+		switch {
+		case request.Method == http.MethodGet && action == "":
+			letters, err := ReadDeadLetters(c.outbox.cfg.DeadLetterFile)
+			if err != nil {
+				writeJsonError(writer, http.StatusInternalServerError, err.Error())
+				return
+			}
+			writeJson(writer, http.StatusOK, letters)
+
+		case request.Method == http.MethodPost && action == "replay":
+			body := struct {
+				Ids []string `json:"ids"`
+			}{}
+			if err := json.NewDecoder(request.Body).Decode(&body); err != nil && err != io.EOF {
+				writeJsonError(writer, http.StatusBadRequest, err.Error())
+				return
+			}
+			queued, dropped, err := c.outbox.replay(body.Ids)
+			if err != nil {
+				writeJsonError(writer, http.StatusInternalServerError, err.Error())
+				return
+			}
+			writeJson(writer, http.StatusOK, map[string][]*Delivery{"queued": queued, "dropped": dropped})
+
+		default:
+			writeJsonError(writer, http.StatusMethodNotAllowed, request.Method+" is not supported on "+request.URL.Path)
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package metis
+
+import (
+	"context"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	"time"
+)
+
+// escalationInterval is how often pending escalations are checked.
+const escalationInterval = 30 * time.Second
+
+// EscalationPolicy re-notifies alarms that stay unresolved. The first policy matching the severity and the sequencer
+// of an alarm is used, an empty list matches everything.
+//
+// The alarm is first sent to Destinations (every enabled destination when empty), then to the destinations of each
+// step once the alarm is older than its after_minutes. After the last step the alarm is repeated every
+// repeat_minutes to the destinations of the last step, or of the policy when it has no steps.
+type EscalationPolicy struct {
+	Name          string           `toml:"name"`
+	Severities    []string         `toml:"severities"`
+	Sequencers    []string         `toml:"sequencers"`
+	Destinations  []string         `toml:"destinations"`
+	RepeatMinutes int              `toml:"repeat_minutes"`
+	Steps         []EscalationStep `toml:"steps"`
+}
+
+type EscalationStep struct {
+	AfterMinutes int      `toml:"after_minutes"`
+	Destinations []string `toml:"destinations"`
+}
+
+// escalation is the progress of an unresolved alarm through its policy, it is kept in alarmCache so that it
+// survives a restart.
+type escalation struct {
+	Policy    string    `json:"policy"`
+	Sequencer string    `json:"sequencer"`
+	Message   string    `json:"message"`
+	Severity  string    `json:"severity"`
+	UniqueId  string    `json:"unique_id"`
+	Since     time.Time `json:"since"`
+	LastSent  time.Time `json:"last_sent"`
+	// Step is the number of steps already notified.
+	Step int `json:"step"`
+
+	// confirmed is false for an escalation restored from the state file until the alarm fires again.
+	confirmed bool
+}
+
+func (p *EscalationPolicy) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("escalation policies need a name")
+	}
+	known := make(map[string]bool)
+	for _, n := range registeredNotifiers() {
+		known[n.Name()] = true
+	}
+	checkDestinations := func(where string, dests []string) error {
+		for _, d := range dests {
+			if !known[d] {
+				return fmt.Errorf("escalation %s: unknown destination %q in %s", p.Name, d, where)
+			}
+		}
+		return nil
+	}
+	if err := checkDestinations("destinations", p.Destinations); err != nil {
+		return err
+	}
+	last := 0
+	for i, step := range p.Steps {
+		if step.AfterMinutes <= last {
+			return fmt.Errorf("escalation %s: after_minutes of step %d must be greater than %d", p.Name, i+1, last)
+		}
+		if len(step.Destinations) == 0 {
+			return fmt.Errorf("escalation %s: step %d has no destinations", p.Name, i+1)
+		}
+		if err := checkDestinations(fmt.Sprintf("step %d", i+1), step.Destinations); err != nil {
+			return err
+		}
+		last = step.AfterMinutes
+	}
+	if p.RepeatMinutes < 0 {
+		return fmt.Errorf("escalation %s: repeat_minutes can't be negative", p.Name)
+	}
+	return nil
+}
+
+func (p *EscalationPolicy) matches(seqName, severity string) bool {
+	return (len(p.Severities) == 0 || contains(p.Severities, severity)) &&
+		(len(p.Sequencers) == 0 || contains(p.Sequencers, seqName))
+}
+
+// next returns the destinations due for an escalation at now, and whether anything is due.
+func (p *EscalationPolicy) next(e *escalation, now time.Time) ([]string, bool) {
+	if e.Step < len(p.Steps) {
+		step := p.Steps[e.Step]
+		if now.Before(e.Since.Add(time.Duration(step.AfterMinutes) * time.Minute)) {
+			return nil, false
+		}
+		return step.Destinations, true
+	}
+	if p.RepeatMinutes == 0 || now.Before(e.LastSent.Add(time.Duration(p.RepeatMinutes)*time.Minute)) {
+		return nil, false
+	}
+	if len(p.Steps) > 0 {
+		return p.Steps[len(p.Steps)-1].Destinations, true
+	}
+	return p.Destinations, true
+}
+
+func (c *MetisianClient) escalationPolicy(seqName, severity string) *EscalationPolicy {
+	for _, p := range c.thresholds().escalations {
+		if p.matches(seqName, severity) {
+			return p
+		}
+	}
+	return nil
+}
+
+func escalationPolicyByName(policies []*EscalationPolicy, name string) *EscalationPolicy {
+	for _, p := range policies {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// startEscalation tracks a newly fired alarm. An alarm restored from the state file keeps its progress.
+func (a *alarmCache) startEscalation(key string, p *EscalationPolicy, msg *alertMsg) {
+	a.notifyMux.Lock()
+	defer a.notifyMux.Unlock()
+	if e := a.Escalations[key]; e != nil {
+		e.confirmed = true
+		return
+	}
+	now := time.Now()
+	a.Escalations[key] = &escalation{
+		Policy:    p.Name,
+		Sequencer: msg.sequencer,
+		Message:   msg.message,
+		Severity:  msg.severity,
+		UniqueId:  msg.uniqueId,
+		Since:     now,
+		LastSent:  now,
+		confirmed: true,
+	}
+}
+
+// stopEscalation forgets a resolved alarm. It returns the destinations that received the alarm, or nil when the
+// alarm wasn't escalated.
+func (a *alarmCache) stopEscalation(key string) []string {
+	a.notifyMux.Lock()
+	defer a.notifyMux.Unlock()
+	if a.Escalations[key] == nil {
+		return nil
+	}
+	delete(a.Escalations, key)
+	delivered := make([]string, 0)
+	for service, sent := range a.Sent {
+		if !sent[key].IsZero() {
+			delivered = append(delivered, service)
+		}
+	}
+	return delivered
+}
+
+// escalate periodically re-notifies the alarms which are due according to their policy. Alarms restored from the
+// state file which didn't fire again within the grace period are resolved, the condition cleared while metisian was
+// down. The policies are read before notifyMux is taken, the lock of the sequencers is never taken under it.
+func (c *MetisianClient) escalate(ctx context.Context) {
+	ticker := time.NewTicker(escalationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		t := c.thresholds()
+		grace := time.Duration(t.nodeDownMin)*time.Minute + 10*time.Minute
+		type dueAlarm struct {
+			escalation   escalation
+			destinations []string
+		}
+		var (
+			due   []dueAlarm
+			stale []firedAlarm
+		)
+		c.alarms.notifyMux.Lock()
+		for _, f := range c.alarms.Fired {
+			if !f.confirmed && now.Sub(c.startedAt) > grace {
+				stale = append(stale, *f)
+			}
+		}
+		for key, e := range c.alarms.Escalations {
+			if !e.confirmed {
+				continue
+			}
+			p := escalationPolicyByName(t.escalations, e.Policy)
+			if p == nil {
+				log.Warn(fmt.Sprintf("escalation policy %s no longer exists, dropping escalation of %s", e.Policy, e.Message))
+				delete(c.alarms.Escalations, key)
+				continue
+			}
+			dests, ok := p.next(e, now)
+			if !ok || c.isSilenced(e.Sequencer, e.UniqueId) {
+				continue
+			}
+			if e.Step < len(p.Steps) {
+				e.Step++
+			}
+			e.LastSent = now
+			due = append(due, dueAlarm{escalation: *e, destinations: dests})
+		}
+		c.alarms.notifyMux.Unlock()
+
+		for _, d := range due {
+			e := d.escalation
+			msg := c.newAlertMsg(e.Sequencer, e.Message, e.Severity, false, e.UniqueId)
+			msg.destinations = d.destinations
+			msg.renotify = true
+			msg.logger().With(log.Fields{"destinations": msg.destinations}).Info("📣 escalating alarm: " + msg.message)
+			c.alertChan <- msg
+		}
+		for _, e := range stale {
+			log.With(log.Fields{"sequencer": e.Sequencer, "alert_id": e.UniqueId}).Info("alarm didn't fire again after restarting, resolving it: " + e.Message)
+			id := e.UniqueId
+			c.alert(e.Sequencer, e.Message, "info", true, false, &id)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,107 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Sequencer is the current status of a sequencer.
+type Sequencer struct {
+	Name              string  `json:"name"`
+	Address           string  `json:"address"`
+	Jailed            bool    `json:"jailed"`
+	IsProducing       bool    `json:"isProducing"`
+	ActiveAlerts      int     `json:"activeAlerts"`
+	LastError         string  `json:"lastError"`
+	Epochs            []int64 `json:"epochs"`
+	Blocks            []int   `json:"blocks"`
+	TotalSigns        int64   `json:"totalSigns"`
+	TotalProposed     int64   `json:"totalProposed"`
+	TotalMissed       int64   `json:"totalMissed"`
+	PrevoteMissed     int64   `json:"prevoteMissed"`
+	PrecommitMissed   int64   `json:"precommitMissed"`
+	ConsecutiveMissed int64   `json:"consecutiveMissed"`
+}
+
+// SignStatus uses the status names of the history store.
+type SignStatus string
+
+func (s SignStatus) MarshalGQL(w io.Writer) {
+	_, _ = io.WriteString(w, strconv.Quote(string(s)))
+}
+
+func (s *SignStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("sign status must be a string")
+	}
+	*s = SignStatus(str)
+	return nil
+}
+
+// Sign is the status of a sequencer at a height. Time is only known for heights read from the history backend.
+type Sign struct {
+	Sequencer string     `json:"sequencer"`
+	Height    int64      `json:"height"`
+	Status    SignStatus `json:"status"`
+	Time      *time.Time `json:"time"`
+}
+
+type Epoch struct {
+	Sequencer   string     `json:"sequencer"`
+	Address     string     `json:"address"`
+	EpochID     int64      `json:"epochId"`
+	StartBlock  int64      `json:"startBlock"`
+	EndBlock    int64      `json:"endBlock"`
+	Recommited  bool       `json:"recommited"`
+	Transaction string     `json:"transaction"`
+	Time        *time.Time `json:"time"`
+}
+
+// Alarm is an alarm which hasn't been resolved.
+type Alarm struct {
+	Sequencer string    `json:"sequencer"`
+	Message   string    `json:"message"`
+	Since     time.Time `json:"since"`
+}
+
+type AlertRecord struct {
+	Sequencer string    `json:"sequencer"`
+	AlertID   string    `json:"alertId"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+	Resolved  bool      `json:"resolved"`
+	Notified  bool      `json:"notified"`
+	Time      time.Time `json:"time"`
+}
+
+// Node is the standing of a node in the endpoint pool.
+type Node struct {
+	RpcURL     string     `json:"rpcUrl"`
+	Score      float64    `json:"score"`
+	Active     bool       `json:"active"`
+	Down       bool       `json:"down"`
+	CatchingUp bool       `json:"catchingUp"`
+	Forked     bool       `json:"forked"`
+	Height     int64      `json:"height"`
+	Lag        int64      `json:"lag"`
+	LatencyMs  int64      `json:"latencyMs"`
+	ErrorRate  float64    `json:"errorRate"`
+	LastError  string     `json:"lastError"`
+	LastCheck  *time.Time `json:"lastCheck"`
+}
+
+type Silence struct {
+	ID         string     `json:"id"`
+	Sequencer  string     `json:"sequencer"`
+	AlertID    string     `json:"alertId"`
+	Until      *time.Time `json:"until"`
+	Schedule   string     `json:"schedule"`
+	Duration   string     `json:"duration"`
+	Comment    string     `json:"comment"`
+	CreatedBy  string     `json:"createdBy"`
+	FromConfig bool       `json:"fromConfig"`
+	Active     bool       `json:"active"`
+}
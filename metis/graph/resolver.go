@@ -0,0 +1,58 @@
+// Package graph serves the monitoring data over GraphQL. The schema is in schema.graphqls, run go generate after
+// changing it.
+package graph
+
+//go:generate go run github.com/99designs/gqlgen generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/b-harvest/metisian/metis/store"
+)
+
+// Source provides the data served over GraphQL, it is implemented by the monitor.
+type Source interface {
+	Sequencers() []*Sequencer
+	Signs(ctx context.Context, f store.Filter) ([]*Sign, error)
+	Epochs(ctx context.Context, f store.Filter) ([]*Epoch, error)
+	Alarms(ctx context.Context, sequencer string) []*Alarm
+	// AlertHistory needs a history backend.
+	AlertHistory(ctx context.Context, f store.Filter) ([]*AlertRecord, error)
+	Nodes() []*Node
+	Silences() []*Silence
+	// Updates sends the sequencers as their status changes, until ctx is done.
+	Updates(ctx context.Context) <-chan *Sequencer
+}
+
+// Resolver resolves every query from the Source.
+type Resolver struct {
+	Source Source
+}
+
+// maxPage bounds the records returned by a single query.
+const maxPage = 1000
+
+func checkPage(f store.Filter) error {
+	if f.Limit < 0 || f.Offset < 0 {
+		return errors.New("limit and offset can't be negative")
+	}
+	if f.Limit > maxPage {
+		return fmt.Errorf("limit can't be more than %d", maxPage)
+	}
+	return nil
+}
+
+func intValue(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
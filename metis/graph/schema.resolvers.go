@@ -0,0 +1,104 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.44
+
+import (
+	"context"
+	"time"
+
+	"github.com/b-harvest/metisian/metis/store"
+)
+
+// Sequencers is the resolver for the sequencers field.
+func (r *queryResolver) Sequencers(ctx context.Context) ([]*Sequencer, error) {
+	return r.Source.Sequencers(), nil
+}
+
+// Sequencer is the resolver for the sequencer field.
+func (r *queryResolver) Sequencer(ctx context.Context, name string) (*Sequencer, error) {
+	for _, seq := range r.Source.Sequencers() {
+		if seq.Name == name {
+			return seq, nil
+		}
+	}
+	return nil, nil
+}
+
+// Signs is the resolver for the signs field.
+func (r *queryResolver) Signs(ctx context.Context, sequencer string, fromHeight *int, toHeight *int, limit *int, offset *int) ([]*Sign, error) {
+	f := store.Filter{Sequencer: sequencer, Limit: intValue(limit), Offset: intValue(offset)}
+	f.FromHeight, f.ToHeight = int64(intValue(fromHeight)), int64(intValue(toHeight))
+	if err := checkPage(f); err != nil {
+		return nil, err
+	}
+	return r.Source.Signs(ctx, f)
+}
+
+// Epochs is the resolver for the epochs field.
+func (r *queryResolver) Epochs(ctx context.Context, sequencer *string, limit *int, offset *int) ([]*Epoch, error) {
+	f := store.Filter{Sequencer: stringValue(sequencer), Limit: intValue(limit), Offset: intValue(offset)}
+	if err := checkPage(f); err != nil {
+		return nil, err
+	}
+	return r.Source.Epochs(ctx, f)
+}
+
+// Alarms is the resolver for the alarms field.
+func (r *queryResolver) Alarms(ctx context.Context, sequencer *string) ([]*Alarm, error) {
+	return r.Source.Alarms(ctx, stringValue(sequencer)), nil
+}
+
+// AlertHistory is the resolver for the alertHistory field.
+func (r *queryResolver) AlertHistory(ctx context.Context, sequencer *string, from *time.Time, to *time.Time, limit *int, offset *int) ([]*AlertRecord, error) {
+	f := store.Filter{Sequencer: stringValue(sequencer), Limit: intValue(limit), Offset: intValue(offset)}
+	if from != nil {
+		f.From = *from
+	}
+	if to != nil {
+		f.To = *to
+	}
+	if err := checkPage(f); err != nil {
+		return nil, err
+	}
+	return r.Source.AlertHistory(ctx, f)
+}
+
+// Nodes is the resolver for the nodes field.
+func (r *queryResolver) Nodes(ctx context.Context) ([]*Node, error) {
+	return r.Source.Nodes(), nil
+}
+
+// Silences is the resolver for the silences field.
+func (r *queryResolver) Silences(ctx context.Context) ([]*Silence, error) {
+	return r.Source.Silences(), nil
+}
+
+// SequencerStatus is the resolver for the sequencerStatus field.
+func (r *subscriptionResolver) SequencerStatus(ctx context.Context, name *string) (<-chan *Sequencer, error) {
+	updates := make(chan *Sequencer)
+	go func() {
+		defer close(updates)
+		for seq := range r.Source.Updates(ctx) {
+			if name != nil && seq.Name != *name {
+				continue
+			}
+			select {
+			case updates <- seq:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
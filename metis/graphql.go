@@ -0,0 +1,254 @@
+package metis
+
+import (
+	"context"
+	"errors"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+	dash "github.com/b-harvest/metisian/metis/dashboard"
+	"github.com/b-harvest/metisian/metis/graph"
+	"github.com/b-harvest/metisian/metis/store"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// graphSource serves the state of the client over GraphQL.
+type graphSource struct {
+	c *MetisianClient
+}
+
+// graphqlHandler serves queries over GET and POST, and subscriptions over a websocket.
+func (c *MetisianClient) graphqlHandler() http.Handler {
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{Source: graphSource{c: c}}}))
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin:       dash.CheckOrigin,
+			EnableCompression: true,
+		},
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+	return srv
+}
+
+// graphqlPlayground serves a page to try queries against the endpoint.
+func graphqlPlayground(endpoint string) http.Handler {
+	return playground.Handler("Metisian", endpoint)
+}
+
+func (c *MetisianClient) sequencerModel(seq *Sequencer) *graph.Sequencer {
+	m := &graph.Sequencer{
+		Name:              seq.name,
+		Address:           seq.Address,
+		ActiveAlerts:      c.alarms.getCount(seq.name),
+		LastError:         seq.lastError,
+		Epochs:            make([]int64, 0),
+		Blocks:            seq.blocksResults,
+		TotalSigns:        int64(seq.statTotalSigns),
+		TotalProposed:     int64(seq.statTotalProps),
+		TotalMissed:       int64(seq.statTotalMiss),
+		PrevoteMissed:     int64(seq.statPrevoteMiss),
+		PrecommitMissed:   int64(seq.statPrecommitMiss),
+		ConsecutiveMissed: int64(seq.statConsecutiveMiss),
+	}
+	if m.Blocks == nil {
+		m.Blocks = make([]int, 0)
+	}
+	if seq.valInfo != nil {
+		m.Jailed = seq.valInfo.Jailed
+	}
+	if c.HideLogs {
+		m.LastError = ""
+	}
+	if seq.statSeqData != nil {
+		m.IsProducing = seq.statSeqData.IsNow
+		for _, e := range seq.statSeqData.Epoches {
+			eid, _ := strconv.ParseInt(e.ID, 0, 64)
+			m.Epochs = append(m.Epochs, eid)
+		}
+	}
+	return m
+}
+
+func (s graphSource) Sequencers() []*graph.Sequencer {
+	seqs := s.c.GetSequencers()
+	result := make([]*graph.Sequencer, 0, len(seqs))
+	for _, seq := range seqs {
+		result = append(result, s.c.sequencerModel(seq))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (s graphSource) Signs(ctx context.Context, f store.Filter) ([]*graph.Sign, error) {
+	recs, err := s.c.signRecords(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*graph.Sign, 0, len(recs))
+	for _, rec := range recs {
+		m := &graph.Sign{Sequencer: rec.Sequencer, Height: rec.Height, Status: graph.SignStatus(rec.Status)}
+		if !rec.Time.IsZero() {
+			t := rec.Time
+			m.Time = &t
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+func (s graphSource) Epochs(ctx context.Context, f store.Filter) ([]*graph.Epoch, error) {
+	recs, err := s.c.epochRecords(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*graph.Epoch, 0, len(recs))
+	for _, rec := range recs {
+		m := &graph.Epoch{
+			Sequencer:   rec.Sequencer,
+			Address:     rec.Address,
+			EpochID:     rec.EpochId,
+			StartBlock:  rec.StartBlock,
+			EndBlock:    rec.EndBlock,
+			Recommited:  rec.Recommited,
+			Transaction: rec.Transaction,
+		}
+		if !rec.Time.IsZero() {
+			t := rec.Time
+			m.Time = &t
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+func (s graphSource) Alarms(ctx context.Context, sequencer string) []*graph.Alarm {
+	active := s.c.alarms.active(sequencer)
+	result := make([]*graph.Alarm, 0, len(active))
+	for _, a := range active {
+		result = append(result, &graph.Alarm{Sequencer: a.Sequencer, Message: s.c.alarmMessage(ctx, a.Message), Since: a.Since})
+	}
+	return result
+}
+
+func (s graphSource) AlertHistory(ctx context.Context, f store.Filter) ([]*graph.AlertRecord, error) {
+	if s.c.history == nil {
+		return nil, errors.New("the alert history needs a [history] backend")
+	}
+	f.ChainId = s.c.ChainId
+	recs, err := s.c.history.store.Alerts(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*graph.AlertRecord, 0, len(recs))
+	for _, rec := range recs {
+		result = append(result, &graph.AlertRecord{
+			Sequencer: rec.Sequencer,
+			AlertID:   rec.AlertId,
+			Severity:  rec.Severity,
+			Message:   s.c.alarmMessage(ctx, rec.Message),
+			Resolved:  rec.Resolved,
+			Notified:  rec.Notified,
+			Time:      rec.Time,
+		})
+	}
+	return result, nil
+}
+
+// alarmMessage is an alarm as served to a request, the node urls in it may hold api keys so they are redacted for
+// anyone but an operator, and for everyone when the logs are hidden.
+func (c *MetisianClient) alarmMessage(ctx context.Context, message string) string {
+	if c.HideLogs || dash.RoleIn(ctx) != dash.RoleOperator {
+		return dash.Redact(message)
+	}
+	return message
+}
+
+func (s graphSource) Nodes() []*graph.Node {
+	scores := s.c.publicNodeScores()
+	result := make([]*graph.Node, 0, len(scores))
+	for _, ns := range scores {
+		m := &graph.Node{
+			RpcURL:     ns.RpcURL,
+			Score:      ns.Score,
+			Active:     ns.Active,
+			Down:       ns.Down,
+			CatchingUp: ns.Syncing,
+			Forked:     ns.Forked,
+			Height:     ns.Height,
+			Lag:        ns.Lag,
+			LatencyMs:  ns.LatencyMs,
+			ErrorRate:  ns.ErrorRate,
+			LastError:  ns.LastError,
+		}
+		if !ns.LastCheck.IsZero() {
+			t := ns.LastCheck
+			m.LastCheck = &t
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+func (s graphSource) Silences() []*graph.Silence {
+	now := time.Now()
+	silences := s.c.getSilences()
+	result := make([]*graph.Silence, 0, len(silences))
+	for _, sl := range silences {
+		m := &graph.Silence{
+			ID:         sl.Id,
+			Sequencer:  sl.Sequencer,
+			AlertID:    sl.AlertId,
+			Schedule:   sl.Schedule,
+			Duration:   sl.Duration,
+			Comment:    sl.Comment,
+			CreatedBy:  sl.CreatedBy,
+			FromConfig: sl.FromConfig,
+			Active:     sl.active(now),
+		}
+		if !sl.Until.IsZero() {
+			until := sl.Until
+			m.Until = &until
+		}
+		result = append(result, m)
+	}
+	return result
+}
+
+// Updates sends a sequencer every time a block has been processed for it.
+func (s graphSource) Updates(ctx context.Context) <-chan *graph.Sequencer {
+	updates := make(chan *graph.Sequencer)
+	listener := s.c.statusCast.Listen()
+	go func() {
+		defer close(updates)
+		defer listener.Discard()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case name, ok := <-listener.Channel():
+				if !ok {
+					return
+				}
+				seq := s.c.sequencers()[name.(string)]
+				if seq == nil {
+					continue
+				}
+				select {
+				case updates <- s.c.sequencerModel(seq):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return updates
+}
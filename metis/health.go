@@ -0,0 +1,187 @@
+package metis
+
+import (
+	"context"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	"sync"
+	"time"
+)
+
+// nodeState is a snapshot of the health of a node.
+type nodeState struct {
+	down      bool
+	wasDown   bool
+	syncing   bool
+	lastMsg   string
+	downSince time.Time
+	lastCheck time.Time
+	height    int64
+	latency   time.Duration
+	// forked is set when the node disagrees with the majority of its peers on a block hash.
+	forked bool
+	// failures holds the outcome of the last probes, true for a failed probe.
+	failures []bool
+}
+
+// probeHistory is the number of probes the error rate is computed from.
+const probeHistory = 20
+
+func (s *nodeState) record(failed bool) {
+	s.failures = append(s.failures, failed)
+	if len(s.failures) > probeHistory {
+		s.failures = s.failures[len(s.failures)-probeHistory:]
+	}
+}
+
+// errorRate is the share of the recent probes which failed.
+func (s *nodeState) errorRate() float64 {
+	if len(s.failures) == 0 {
+		return 0
+	}
+	failed := 0
+	for _, f := range s.failures {
+		if f {
+			failed++
+		}
+	}
+	return float64(failed) / float64(len(s.failures))
+}
+
+// nodeHealth is the health of a node as seen by probing its own rpc_url. NodeInfo is passed around by value, the
+// health is shared by every copy through a pointer.
+type nodeHealth struct {
+	mux   sync.RWMutex
+	state nodeState
+}
+
+func (h *nodeHealth) snapshot() nodeState {
+	h.mux.RLock()
+	defer h.mux.RUnlock()
+	st := h.state
+	st.failures = append([]bool{}, h.state.failures...)
+	return st
+}
+
+// markDown records a node which can't be used, a node catching up still answered so it doesn't count as an error.
+// It returns whether the node was up until now.
+func (h *nodeHealth) markDown(msg string, syncing bool) (wentDown bool) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.state.record(!syncing)
+	if !h.state.down {
+		wentDown = true
+		h.state.down = true
+		h.state.downSince = time.Now()
+	}
+	h.state.syncing = syncing
+	h.state.lastMsg = msg
+	h.state.lastCheck = time.Now()
+	return
+}
+
+// markDownSince restores a node which was down when metisian stopped.
+func (h *nodeHealth) markDownSince(since time.Time) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.state.down = true
+	h.state.downSince = since
+}
+
+// markUp records a healthy node, its latency is smoothed over the recent probes. It returns whether the node was down
+// until now.
+func (h *nodeHealth) markUp(height int64, latency time.Duration) (cameUp bool) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.state.record(false)
+	if h.state.latency > 0 {
+		latency = (7*h.state.latency + 3*latency) / 10
+	}
+	if h.state.down {
+		cameUp = true
+		h.state.lastMsg = ""
+		h.state.wasDown = true
+	}
+	h.state.down = false
+	h.state.syncing = false
+	h.state.downSince = time.Time{}
+	h.state.height = height
+	h.state.latency = latency
+	h.state.lastCheck = time.Now()
+	return
+}
+
+func (h *nodeHealth) setForked(forked bool) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.state.forked = forked
+}
+
+func (h *nodeHealth) clearWasDown() {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.state.wasDown = false
+}
+
+// probe checks a node through its own rpc_url, it is down when it can't be reached, is on another network or is
+// still catching up.
+func (c *MetisianClient) probe(ctx context.Context, node NodeInfo) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	status, err := getStatusWithEndpoint(ctx, node.RpcURL)
+	latency := time.Since(start)
+
+	var msg string
+	switch {
+	case err != nil:
+		msg = fmt.Sprintf("node %s is down: %v", node.RpcURL, err)
+	case status.network != c.ChainId:
+		msg = fmt.Sprintf("node %s is on the wrong network %s", node.RpcURL, status.network)
+	case status.catchingUp:
+		msg = fmt.Sprintf("node %s is not synced", node.RpcURL)
+	default:
+		if node.health.snapshot().down {
+			log.With(log.Fields{"rpc_url": node.RpcURL}).Info("🟢 node is healthy again")
+		} else {
+			log.With(log.Fields{"rpc_url": node.RpcURL}).Debug("🟢 node is healthy")
+		}
+		if node.health.markUp(status.height, latency) {
+			c.recordNodeEvent(node.RpcURL, true, "")
+		}
+		return
+	}
+
+	if node.health.markDown(msg, err == nil && status.catchingUp) {
+		c.recordNodeEvent(node.RpcURL, false, msg)
+	}
+	if node.AlertIfDown {
+		log.With(log.Fields{"rpc_url": node.RpcURL}).Warn("⚠️ " + msg)
+	}
+}
+
+// probeNodes checks every node concurrently, and returns once all of them are done.
+func (c *MetisianClient) probeNodes(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, node := range c.nodes() {
+		wg.Add(1)
+		go func(node NodeInfo) {
+			defer wg.Done()
+			c.probe(ctx, node)
+		}(node)
+	}
+	wg.Wait()
+
+	c.noNodes = c.healthyNodes() == 0
+}
+
+func (c *MetisianClient) healthyNodes() int {
+	healthy := 0
+	for _, node := range c.nodes() {
+		if !node.health.snapshot().down {
+			healthy++
+		}
+	}
+	return healthy
+}
@@ -0,0 +1,230 @@
+package metis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	"github.com/b-harvest/metisian/metis/store"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// historyBuffer is how many records may wait for the history backend, records are dropped when it is full so that a
+// slow database never holds up the monitoring.
+const historyBuffer = 1024
+
+var signStatus = map[StatusType]string{
+	Statusmissed:    store.StatusMissed,
+	StatusPrevote:   store.StatusPrevote,
+	StatusPrecommit: store.StatusPrecommit,
+	StatusSigned:    store.StatusSigned,
+	StatusProposed:  store.StatusProposed,
+}
+
+// history writes records to the history backend in the background.
+type history struct {
+	store   store.Store
+	records chan func(ctx context.Context, s store.Store) error
+
+	// epochs holds the epochs already saved, with their recommited flag, the subgraph returns them on every poll.
+	epochs   map[string]bool
+	epochMux sync.Mutex
+}
+
+func newHistory(s store.Store) *history {
+	return &history{
+		store:   s,
+		records: make(chan func(ctx context.Context, s store.Store) error, historyBuffer),
+		epochs:  make(map[string]bool),
+	}
+}
+
+// run saves the records until ctx is done, and then closes the backend.
+func (h *history) run(ctx context.Context) {
+	defer func() {
+		if err := h.store.Close(); err != nil {
+			log.Warn(fmt.Sprintf("closing history: %v", err))
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case save := <-h.records:
+			sCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			if err := save(sCtx, h.store); err != nil {
+				log.Warn(fmt.Sprintf("📚 saving history: %v", err))
+			}
+			cancel()
+		}
+	}
+}
+
+func (c *MetisianClient) record(save func(ctx context.Context, s store.Store) error) {
+	if c.history == nil {
+		return
+	}
+	select {
+	case c.history.records <- save:
+	default:
+		log.Warn("📚 history backend is falling behind, dropping a record")
+	}
+}
+
+func (c *MetisianClient) recordSign(seq *Sequencer, height int64, status StatusType) {
+	rec := &store.SignRecord{
+		ChainId:   c.ChainId,
+		Sequencer: seq.name,
+		Address:   seq.Address,
+		Height:    height,
+		Status:    signStatus[status],
+		Time:      time.Now().UTC(),
+	}
+	c.record(func(ctx context.Context, s store.Store) error {
+		return s.SaveSign(ctx, rec)
+	})
+}
+
+// recordAlert saves an alert, notified is false when it was silenced or only tracked.
+func (c *MetisianClient) recordAlert(seqName, uniq, message, severity string, resolved, notified bool) {
+	rec := &store.AlertRecord{
+		ChainId:   c.ChainId,
+		Sequencer: seqName,
+		AlertId:   uniq,
+		Severity:  severity,
+		Message:   message,
+		Resolved:  resolved,
+		Notified:  notified,
+		Time:      time.Now().UTC(),
+	}
+	c.record(func(ctx context.Context, s store.Store) error {
+		return s.SaveAlert(ctx, rec)
+	})
+}
+
+func (c *MetisianClient) recordNodeEvent(rpcUrl string, up bool, message string) {
+	rec := &store.NodeEvent{
+		ChainId: c.ChainId,
+		RpcURL:  rpcUrl,
+		Up:      up,
+		Message: message,
+		Time:    time.Now().UTC(),
+	}
+	c.record(func(ctx context.Context, s store.Store) error {
+		return s.SaveNodeEvent(ctx, rec)
+	})
+}
+
+// recordEpochs saves the epochs of a sequencer which are new or were recommited since the last poll.
+func (c *MetisianClient) recordEpochs(seq *Sequencer, epochs []*Epoch) {
+	if c.history == nil {
+		return
+	}
+	c.history.epochMux.Lock()
+	defer c.history.epochMux.Unlock()
+	for _, e := range epochs {
+		key := seq.Address + "/" + e.ID
+		if recommited, ok := c.history.epochs[key]; ok && recommited == e.Recommited {
+			continue
+		}
+		c.history.epochs[key] = e.Recommited
+
+		rec := epochRecord(c.ChainId, seq, e)
+		if rec.Time.IsZero() {
+			rec.Time = time.Now().UTC()
+		}
+		c.record(func(ctx context.Context, s store.Store) error {
+			return s.SaveEpoch(ctx, rec)
+		})
+	}
+}
+
+// signRecords reads the sign status of a sequencer from the history backend, or without one from the blocks kept for
+// the dashboard, whose time isn't known.
+func (c *MetisianClient) signRecords(ctx context.Context, f store.Filter) ([]*store.SignRecord, error) {
+	seq := c.sequencers()[f.Sequencer]
+	if seq == nil || seq.name == MetisianName {
+		return nil, errors.New("unknown sequencer " + f.Sequencer)
+	}
+	f.ChainId = c.ChainId
+	if c.history != nil {
+		return c.history.store.Signs(ctx, f)
+	}
+
+	// blocksResults holds the newest block first.
+	recs := make([]*store.SignRecord, 0)
+	skipped := 0
+	for i, status := range seq.blocksResults {
+		height := c.lastBlockNum - int64(i)
+		if status < 0 || height <= 0 || (f.ToHeight > 0 && height > f.ToHeight) {
+			continue
+		}
+		if f.FromHeight > 0 && height < f.FromHeight || f.Limit > 0 && len(recs) == f.Limit {
+			break
+		}
+		if skipped < f.Offset {
+			skipped++
+			continue
+		}
+		recs = append(recs, &store.SignRecord{
+			ChainId:   c.ChainId,
+			Sequencer: seq.name,
+			Address:   seq.Address,
+			Height:    height,
+			Status:    signStatus[StatusType(status)],
+		})
+	}
+	return recs, nil
+}
+
+// epochRecords reads the epochs from the history backend, or without one the epochs last returned by the subgraph.
+func (c *MetisianClient) epochRecords(ctx context.Context, f store.Filter) ([]*store.EpochRecord, error) {
+	f.ChainId = c.ChainId
+	if c.history != nil {
+		return c.history.store.Epochs(ctx, f)
+	}
+
+	recs := make([]*store.EpochRecord, 0)
+	for _, seq := range c.GetSequencers() {
+		if seq.statSeqData == nil || (f.Sequencer != "" && seq.name != f.Sequencer) {
+			continue
+		}
+		for _, e := range seq.statSeqData.Epoches {
+			rec := epochRecord(c.ChainId, seq, e)
+			if (f.FromHeight > 0 && rec.EndBlock < f.FromHeight) || (f.ToHeight > 0 && rec.StartBlock > f.ToHeight) {
+				continue
+			}
+			recs = append(recs, rec)
+		}
+	}
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].EpochId > recs[j].EpochId })
+	if f.Offset >= len(recs) {
+		return recs[:0], nil
+	}
+	recs = recs[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(recs) {
+		recs = recs[:f.Limit]
+	}
+	return recs, nil
+}
+
+// epochRecord converts an epoch from the subgraph, its time is the time of the block which created it.
+func epochRecord(chainId string, seq *Sequencer, e *Epoch) *store.EpochRecord {
+	rec := &store.EpochRecord{
+		ChainId:     chainId,
+		Sequencer:   seq.name,
+		Address:     seq.Address,
+		Recommited:  e.Recommited,
+		Transaction: e.Transaction,
+	}
+	rec.EpochId, _ = strconv.ParseInt(e.ID, 0, 64)
+	rec.StartBlock, _ = strconv.ParseInt(e.StartBlock, 0, 64)
+	rec.EndBlock, _ = strconv.ParseInt(e.EndBlock, 0, 64)
+	if ts, err := strconv.ParseInt(e.BlockTimestamp, 0, 64); err == nil && ts > 0 {
+		rec.Time = time.Unix(ts, 0).UTC()
+	}
+	return rec
+}
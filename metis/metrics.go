@@ -0,0 +1,145 @@
+package metis
+
+import (
+	"errors"
+	"github.com/b-harvest/metisian/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const metricsNamespace = "metisian"
+
+var (
+	seqLabels  = []string{"chain_id", "name", "address"}
+	nodeLabels = []string{"chain_id", "rpc_url"}
+
+	descTotalSigns = prometheus.NewDesc(metricsNamespace+"_sequencer_signed_blocks_total",
+		"Number of blocks signed by the sequencer since metisian started.", seqLabels, nil)
+	descTotalProps = prometheus.NewDesc(metricsNamespace+"_sequencer_proposed_blocks_total",
+		"Number of blocks proposed by the sequencer since metisian started.", seqLabels, nil)
+	descTotalMiss = prometheus.NewDesc(metricsNamespace+"_sequencer_missed_blocks_total",
+		"Number of blocks missed by the sequencer since metisian started.", seqLabels, nil)
+	descPrevoteMiss = prometheus.NewDesc(metricsNamespace+"_sequencer_prevote_missed_blocks_total",
+		"Number of missed blocks where only a prevote of the sequencer was seen.", seqLabels, nil)
+	descPrecommitMiss = prometheus.NewDesc(metricsNamespace+"_sequencer_precommit_missed_blocks_total",
+		"Number of missed blocks where a precommit of the sequencer was seen but not included.", seqLabels, nil)
+	descConsecutiveMiss = prometheus.NewDesc(metricsNamespace+"_sequencer_consecutive_missed_blocks",
+		"Number of blocks the sequencer has missed in a row.", seqLabels, nil)
+	descJailed = prometheus.NewDesc(metricsNamespace+"_sequencer_jailed",
+		"1 if the sequencer is jailed.", seqLabels, nil)
+	descActiveAlerts = prometheus.NewDesc(metricsNamespace+"_sequencer_active_alerts",
+		"Number of alerts currently active for the sequencer.", seqLabels, nil)
+	descCurrentEpoch = prometheus.NewDesc(metricsNamespace+"_sequencer_current_epoch",
+		"Latest epoch (span) id assigned to the sequencer by the sequencer-set.", seqLabels, nil)
+	descProducing = prometheus.NewDesc(metricsNamespace+"_sequencer_producing",
+		"1 if the latest epoch of the sequencer contains the current L2 block.", seqLabels, nil)
+
+	descNodeUp = prometheus.NewDesc(metricsNamespace+"_node_up",
+		"1 if the node is reachable and on the expected network.", nodeLabels, nil)
+	descNodeSyncing = prometheus.NewDesc(metricsNamespace+"_node_syncing",
+		"1 if the node is catching up.", nodeLabels, nil)
+	descNodeScore = prometheus.NewDesc(metricsNamespace+"_node_score",
+		"Score of the node in the endpoint pool, from 0 to 100.", nodeLabels, nil)
+	descNodeActive = prometheus.NewDesc(metricsNamespace+"_node_active",
+		"1 if the websocket subscription uses the node.", nodeLabels, nil)
+
+	descLastBlockHeight = prometheus.NewDesc(metricsNamespace+"_last_block_height",
+		"Height of the last block seen over the websocket.", []string{"chain_id"}, nil)
+	descLastBlockTime = prometheus.NewDesc(metricsNamespace+"_last_block_timestamp_seconds",
+		"Unix time the last block was seen over the websocket.", []string{"chain_id"}, nil)
+)
+
+// metricsCollector reads the current state of the clients on every scrape, so the websocket handlers don't have to
+// keep a second copy of the counters up to date. The networks are told apart by the chain_id label.
+type metricsCollector struct {
+	clients []*MetisianClient
+}
+
+func (mc *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		descTotalSigns, descTotalProps, descTotalMiss, descPrevoteMiss, descPrecommitMiss, descConsecutiveMiss,
+		descJailed, descActiveAlerts, descCurrentEpoch, descProducing,
+		descNodeUp, descNodeSyncing, descNodeScore, descNodeActive,
+		descLastBlockHeight, descLastBlockTime,
+	} {
+		ch <- d
+	}
+}
+
+func (mc *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range mc.clients {
+		c.collect(ch)
+	}
+}
+
+func (c *MetisianClient) collect(ch chan<- prometheus.Metric) {
+	for _, seq := range c.GetSequencers() {
+		labels := []string{c.ChainId, seq.name, seq.Address}
+
+		ch <- prometheus.MustNewConstMetric(descTotalSigns, prometheus.CounterValue, seq.statTotalSigns, labels...)
+		ch <- prometheus.MustNewConstMetric(descTotalProps, prometheus.CounterValue, seq.statTotalProps, labels...)
+		ch <- prometheus.MustNewConstMetric(descTotalMiss, prometheus.CounterValue, seq.statTotalMiss, labels...)
+		ch <- prometheus.MustNewConstMetric(descPrevoteMiss, prometheus.CounterValue, seq.statPrevoteMiss, labels...)
+		ch <- prometheus.MustNewConstMetric(descPrecommitMiss, prometheus.CounterValue, seq.statPrecommitMiss, labels...)
+		ch <- prometheus.MustNewConstMetric(descConsecutiveMiss, prometheus.GaugeValue, seq.statConsecutiveMiss, labels...)
+		ch <- prometheus.MustNewConstMetric(descActiveAlerts, prometheus.GaugeValue, float64(seq.activeAlerts), labels...)
+
+		if seq.valInfo != nil {
+			ch <- prometheus.MustNewConstMetric(descJailed, prometheus.GaugeValue, boolToFloat(seq.valInfo.Jailed), labels...)
+		}
+
+		if seq.statSeqData != nil {
+			ch <- prometheus.MustNewConstMetric(descProducing, prometheus.GaugeValue, boolToFloat(seq.statSeqData.IsNow), labels...)
+			if len(seq.statSeqData.Epoches) > 0 {
+				epoch, err := strconv.ParseInt(seq.statSeqData.Epoches[0].ID, 0, 64)
+				if err == nil {
+					ch <- prometheus.MustNewConstMetric(descCurrentEpoch, prometheus.GaugeValue, float64(epoch), labels...)
+				}
+			}
+		}
+	}
+
+	for _, ns := range c.nodeScores() {
+		labels := []string{c.ChainId, ns.RpcURL}
+		ch <- prometheus.MustNewConstMetric(descNodeUp, prometheus.GaugeValue, boolToFloat(!ns.Down), labels...)
+		ch <- prometheus.MustNewConstMetric(descNodeSyncing, prometheus.GaugeValue, boolToFloat(ns.Syncing), labels...)
+		ch <- prometheus.MustNewConstMetric(descNodeScore, prometheus.GaugeValue, ns.Score, labels...)
+		ch <- prometheus.MustNewConstMetric(descNodeActive, prometheus.GaugeValue, boolToFloat(ns.Active), labels...)
+	}
+
+	if c.lastBlockNum > 0 {
+		ch <- prometheus.MustNewConstMetric(descLastBlockHeight, prometheus.GaugeValue, float64(c.lastBlockNum), c.ChainId)
+		ch <- prometheus.MustNewConstMetric(descLastBlockTime, prometheus.GaugeValue, float64(c.lastBlockTime.Unix()), c.ChainId)
+	}
+}
+
+// serveMetrics exposes the metrics of the clients on /metrics, it blocks until the server fails.
+func serveMetrics(listen string, clients []*MetisianClient) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		&metricsCollector{clients: clients},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	server := &http.Server{
+		Addr:              listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 3 * time.Second,
+	}
+	err := server.ListenAndServe()
+	log.Fatal(errors.New("metisian prometheus exporter failed " + err.Error()))
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
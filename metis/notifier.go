@@ -0,0 +1,122 @@
+package metis
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Notifier is an alert destination. Destinations register themselves with RegisterNotifier from an init func, every
+// alert is offered to every registered Notifier and each one decides from the alert's Destinations whether it is
+// configured for it.
+type Notifier interface {
+	// Name identifies the destination in logs and in alarmCache, it must be unique.
+	Name() string
+	// Enabled reports whether the destination is configured for the alert.
+	Enabled(msg *alertMsg) bool
+	// Send delivers the alert. It is only called after shouldNotify has approved the alert for this destination, and
+	// is retried with backoff when it returns an error.
+	Send(msg *alertMsg) error
+}
+
+// flapSuppressor is implemented by notifiers that should not be triggered again for an alert that fired within the
+// last few minutes.
+type flapSuppressor interface {
+	suppressFlapping() bool
+}
+
+// alarmRestorer is implemented by notifiers keeping track of the alarms they delivered, they are given the alarms
+// restored from the state file which they had received.
+type alarmRestorer interface {
+	restoreAlarm(msg *alertMsg, since time.Time)
+}
+
+// configChecker is implemented by notifiers that can tell what their section of Destinations is missing, it is only
+// called when the destination is enabled.
+type configChecker interface {
+	checkConfig(d Destinations) []string
+}
+
+var (
+	notifiers   []Notifier
+	notifierMux sync.RWMutex
+)
+
+// RegisterNotifier adds a destination to the registry, registering the same name twice panics.
+func RegisterNotifier(n Notifier) {
+	notifierMux.Lock()
+	defer notifierMux.Unlock()
+	for _, registered := range notifiers {
+		if registered.Name() == n.Name() {
+			panic(fmt.Sprintf("notifier %s is already registered", n.Name()))
+		}
+	}
+	notifiers = append(notifiers, n)
+}
+
+func registeredNotifiers() []Notifier {
+	notifierMux.RLock()
+	defer notifierMux.RUnlock()
+	return append([]Notifier{}, notifiers...)
+}
+
+func (msg *alertMsg) sendsTo(name string) bool {
+	return msg.destinations == nil || contains(msg.destinations, name)
+}
+
+// missing reports the settings which are empty.
+func missing(settings map[string]string) []string {
+	problems := make([]string, 0)
+	for key, value := range settings {
+		if value == "" {
+			problems = append(problems, key+" is missing")
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// SendTestAlert sends a test alarm from Metisian to its destinations, or only to the named ones, and then its
+// resolution when resolve is set. It returns the error of every destination tried, nil when it was delivered.
+func SendTestAlert(cfg *Config, destinations []string, resolve bool) (map[string]error, error) {
+	network, err := cfg.network()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool)
+	for _, n := range registeredNotifiers() {
+		known[n.Name()] = true
+	}
+	for _, name := range destinations {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown destination %q", name)
+		}
+	}
+
+	alarm := alertMsg{
+		severity:  "info",
+		sequencer: MetisianName,
+		address:   MetisianName,
+		message:   "test alert, nothing is wrong",
+		uniqueId:  "test",
+		chainId:   network.ChainId,
+		dest:      cfg.Destinations,
+	}
+	results := make(map[string]error)
+	for _, n := range registeredNotifiers() {
+		if !n.Enabled(&alarm) || (len(destinations) > 0 && !contains(destinations, n.Name())) {
+			continue
+		}
+		// notifiers may change the message, every send gets a copy.
+		msg := alarm
+		err = n.Send(&msg)
+		if err == nil && resolve {
+			resolved := alarm
+			resolved.resolved = true
+			err = n.Send(&resolved)
+		}
+		results[n.Name()] = err
+	}
+	return results, nil
+}
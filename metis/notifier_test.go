@@ -0,0 +1,106 @@
+package metis
+
+import (
+	"testing"
+)
+
+// testNotifier accepts every alert, flaps makes it suppress flapping alarms.
+type testNotifier struct {
+	name  string
+	flaps bool
+}
+
+func (n testNotifier) Name() string           { return n.name }
+func (testNotifier) Enabled(*alertMsg) bool   { return true }
+func (testNotifier) Send(*alertMsg) error     { return nil }
+func (n testNotifier) suppressFlapping() bool { return n.flaps }
+
+func TestRegisterNotifier(t *testing.T) {
+	notifierMux.Lock()
+	saved := notifiers
+	notifiers = nil
+	notifierMux.Unlock()
+	defer func() {
+		notifierMux.Lock()
+		notifiers = saved
+		notifierMux.Unlock()
+	}()
+
+	RegisterNotifier(testNotifier{name: "first"})
+	RegisterNotifier(testNotifier{name: "second"})
+	registered := registeredNotifiers()
+	if len(registered) != 2 || registered[0].Name() != "first" || registered[1].Name() != "second" {
+		t.Fatalf("registered %v, want first and second in order", registered)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice didn't panic")
+		}
+	}()
+	RegisterNotifier(testNotifier{name: "first"})
+}
+
+func TestAlertSendsTo(t *testing.T) {
+	tests := []struct {
+		name         string
+		destinations []string
+		notifier     string
+		want         bool
+	}{
+		{"every destination", nil, "slack", true},
+		{"listed", []string{"pagerduty", "slack"}, "slack", true},
+		{"not listed", []string{"pagerduty"}, "slack", false},
+		{"none", []string{}, "slack", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &alertMsg{destinations: tt.destinations}
+			if got := msg.sendsTo(tt.notifier); got != tt.want {
+				t.Errorf("sendsTo(%s) = %v, want %v", tt.notifier, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldNotify(t *testing.T) {
+	plain := testNotifier{name: "plain"}
+	flapping := testNotifier{name: "flapping", flaps: true}
+	tests := []struct {
+		name     string
+		notifier Notifier
+		// deliveredTo already received the alarm.
+		deliveredTo string
+		// firedBefore offered the alarm once already, without delivering it.
+		firedBefore bool
+		resolved    bool
+		renotify    bool
+		want        bool
+	}{
+		{name: "new alarm", notifier: plain, want: true},
+		{name: "already delivered", notifier: plain, deliveredTo: "plain", want: false},
+		{name: "delivered to another destination", notifier: plain, deliveredTo: "flapping", want: true},
+		{name: "renotified", notifier: plain, deliveredTo: "plain", renotify: true, want: true},
+		{name: "resolution of a delivered alarm", notifier: plain, deliveredTo: "plain", resolved: true, want: true},
+		{name: "resolution of an alarm never delivered", notifier: plain, resolved: true, want: false},
+		{name: "offered again", notifier: plain, firedBefore: true, want: true},
+		{name: "flapping", notifier: flapping, firedBefore: true, want: false},
+		{name: "flapping once", notifier: flapping, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAlarmCache()
+			msg := &alertMsg{sequencer: "seq", message: "missed 10 blocks", severity: "warning"}
+			if tt.deliveredTo != "" {
+				a.markDelivered(tt.deliveredTo, msg.sequencer+msg.message, false)
+			}
+			if tt.firedBefore {
+				a.shouldNotify(msg, tt.notifier)
+			}
+			msg.resolved, msg.renotify = tt.resolved, tt.renotify
+			if got := a.shouldNotify(msg, tt.notifier); got != tt.want {
+				t.Errorf("shouldNotify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
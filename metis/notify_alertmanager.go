@@ -0,0 +1,178 @@
+package metis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(&alertmanagerNotifier{active: make(map[string]*firingAlert)})
+}
+
+// alertmanagerResend is how often firing alerts are posted again, alertmanager resolves alerts on its own when they
+// haven't been refreshed within its resolve_timeout (5 minutes by default).
+const alertmanagerResend = time.Minute
+
+// AlertmanagerAlert is a single alert of the alertmanager v2 api, see
+// https://github.com/prometheus/alertmanager/blob/main/api/v2/openapi.yaml
+type AlertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       *time.Time        `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// firingAlert is kept until the alert is resolved, alertmanager identifies an alert by its labels so the resolution
+// has to repeat the labels the alert fired with (the severity usually differs).
+type firingAlert struct {
+	cfg   AlertmanagerConfig
+	alert AlertmanagerAlert
+}
+
+type alertmanagerNotifier struct {
+	mux    sync.Mutex
+	active map[string]*firingAlert
+	resend sync.Once
+}
+
+func (*alertmanagerNotifier) Name() string {
+	return "alertmanager"
+}
+
+func (*alertmanagerNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Alertmanager.Enabled
+}
+
+func (*alertmanagerNotifier) checkConfig(d Destinations) []string {
+	return missing(map[string]string{"url": d.Alertmanager.Url})
+}
+
+// alertmanagerKey identifies an alarm like alarmCache does, the notifier is shared by every network.
+func alertmanagerKey(msg *alertMsg) string {
+	return fmt.Sprintf("%s|%s|%s", msg.dest.Alertmanager.Url, msg.chainId, msg.sequencer+msg.message)
+}
+
+// restoreAlarm keeps refreshing an alarm delivered before a restart.
+func (am *alertmanagerNotifier) restoreAlarm(msg *alertMsg, since time.Time) {
+	am.resend.Do(func() {
+		go am.resendFiring()
+	})
+
+	alert := buildAlertmanagerAlert(msg)
+	alert.StartsAt = since.UTC()
+	am.mux.Lock()
+	am.active[alertmanagerKey(msg)] = &firingAlert{cfg: msg.dest.Alertmanager, alert: alert}
+	am.mux.Unlock()
+}
+
+func (am *alertmanagerNotifier) Send(msg *alertMsg) error {
+	am.resend.Do(func() {
+		go am.resendFiring()
+	})
+
+	cfg := msg.dest.Alertmanager
+	key := alertmanagerKey(msg)
+
+	am.mux.Lock()
+	firing := am.active[key]
+	if msg.resolved {
+		delete(am.active, key)
+	}
+	am.mux.Unlock()
+
+	if msg.resolved {
+		if firing == nil {
+			// not delivered by this run nor restored, the severity the alarm fired with is unknown.
+			log.Warn(fmt.Sprintf("resolving alertmanager alert %s without the labels it fired with", msg.uniqueId))
+			firing = &firingAlert{cfg: cfg, alert: buildAlertmanagerAlert(msg)}
+		}
+		resolved := firing.alert
+		now := time.Now().UTC()
+		resolved.EndsAt = &now
+		return postAlertmanager(cfg, []AlertmanagerAlert{resolved})
+	}
+
+	firing = &firingAlert{cfg: cfg, alert: buildAlertmanagerAlert(msg)}
+	am.mux.Lock()
+	am.active[key] = firing
+	am.mux.Unlock()
+	return postAlertmanager(cfg, []AlertmanagerAlert{firing.alert})
+}
+
+// resendFiring keeps alerts firing in alertmanager until they are resolved.
+func (am *alertmanagerNotifier) resendFiring() {
+	tick := time.NewTicker(alertmanagerResend)
+	defer tick.Stop()
+	for range tick.C {
+		am.mux.Lock()
+		firing := make([]*firingAlert, 0, len(am.active))
+		for _, a := range am.active {
+			firing = append(firing, a)
+		}
+		am.mux.Unlock()
+
+		for _, a := range firing {
+			if err := postAlertmanager(a.cfg, []AlertmanagerAlert{a.alert}); err != nil {
+				log.Warn(fmt.Sprintf("could not refresh alertmanager alert %s: %v", a.alert.Labels["alert_id"], err))
+			}
+		}
+	}
+}
+
+func buildAlertmanagerAlert(msg *alertMsg) AlertmanagerAlert {
+	labels := map[string]string{
+		"alertname": "MetisianAlert",
+		"alert_id":  msg.uniqueId,
+		"sequencer": msg.sequencer,
+		"address":   msg.address,
+		"chain_id":  msg.chainId,
+		"severity":  msg.severity,
+	}
+	for k, v := range msg.dest.Alertmanager.Labels {
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+	return AlertmanagerAlert{
+		Labels: labels,
+		Annotations: map[string]string{
+			"summary":     fmt.Sprintf("%s: %s", msg.sequencer, strings.SplitN(msg.message, "\n", 2)[0]),
+			"description": msg.message,
+		},
+		StartsAt: time.Now().UTC(),
+	}
+}
+
+func postAlertmanager(cfg AlertmanagerConfig, alerts []AlertmanagerAlert) error {
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimRight(cfg.Url, "/")+"/api/v2/alerts", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not notify alertmanager got %d response", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package metis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(discordNotifier{})
+}
+
+type discordNotifier struct{}
+
+func (discordNotifier) Name() string {
+	return "discord"
+}
+
+func (discordNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Discord.Enabled
+}
+
+func (discordNotifier) checkConfig(d Destinations) []string {
+	return missing(map[string]string{"webhook": d.Discord.Webhook})
+}
+
+func (discordNotifier) Send(msg *alertMsg) error {
+	discPost := buildDiscordMessage(msg)
+	client := &http.Client{Timeout: 30 * time.Second}
+	data, err := json.MarshalIndent(discPost, "", "  ")
+	if err != nil {
+		return fmt.Errorf("⚠️ Could not notify discord! %w", err)
+	}
+
+	req, err := http.NewRequest("POST", msg.dest.Discord.Webhook, bytes.NewBuffer(data))
+	if err != nil {
+		return fmt.Errorf("⚠️ Could not notify discord! %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("⚠️ Could not notify discord! %w", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != 204 {
+		return fmt.Errorf("⚠️ Could not notify discord! Returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+type DiscordMessage struct {
+	Username  string         `json:"username,omitempty"`
+	AvatarUrl string         `json:"avatar_url,omitempty"`
+	Content   string         `json:"content"`
+	Embeds    []DiscordEmbed `json:"embeds,omitempty"`
+}
+
+type DiscordEmbed struct {
+	Title       string `json:"title,omitempty"`
+	Url         string `json:"url,omitempty"`
+	Description string `json:"description"`
+	Color       uint   `json:"color"`
+}
+
+func buildDiscordMessage(msg *alertMsg) *DiscordMessage {
+	prefix := ""
+	if msg.resolved {
+		prefix = "💜 Resolved: "
+	}
+	return &DiscordMessage{
+		Username: "Metisian",
+		Content:  prefix + msg.sequencer,
+		Embeds: []DiscordEmbed{{
+			Description: msg.message,
+		}},
+	}
+}
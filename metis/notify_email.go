@@ -0,0 +1,183 @@
+package metis
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(emailNotifier{})
+}
+
+const smtpTimeout = 30 * time.Second
+
+var emailHtml = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<h3 style="color: {{.Color}}">{{.Title}}</h3>
+<table>
+<tr><td><b>Sequencer</b></td><td>{{.Sequencer}}</td></tr>
+<tr><td><b>Chain</b></td><td>{{.ChainId}}</td></tr>
+<tr><td><b>Severity</b></td><td>{{.Severity}}</td></tr>
+<tr><td><b>Alert id</b></td><td>{{.UniqueId}}</td></tr>
+</table>
+<pre>{{.Message}}</pre>
+</body>
+</html>
+`))
+
+type emailNotifier struct{}
+
+func (emailNotifier) Name() string {
+	return "email"
+}
+
+func (emailNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Email.Enabled
+}
+
+func (emailNotifier) checkConfig(d Destinations) []string {
+	problems := missing(map[string]string{"host": d.Email.Host, "from": d.Email.From})
+	if len(d.Email.To) == 0 {
+		problems = append(problems, "to needs at least one recipient")
+	}
+	if d.Email.TLS && d.Email.StartTLS {
+		problems = append(problems, "tls and starttls can't both be set")
+	}
+	return problems
+}
+
+func (emailNotifier) Send(msg *alertMsg) error {
+	cfg := msg.dest.Email
+	if cfg.Host == "" || cfg.From == "" || len(cfg.To) == 0 {
+		return errors.New("email needs host, from and at least one recipient")
+	}
+	body, err := buildEmailMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	port := cfg.Port
+	if port == 0 {
+		port = 25
+		if cfg.TLS {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	if cfg.TLS {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: smtpTimeout}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, smtpTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("could not connect to smtp server %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if cfg.StartTLS && !cfg.TLS {
+		if err = client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if cfg.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err = client.Mail(cfg.From); err != nil {
+		return err
+	}
+	for _, to := range cfg.To {
+		if err = client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp recipient %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(body); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmailMessage creates a multipart/alternative mail with a plain-text and an html version of the alert.
+func buildEmailMessage(msg *alertMsg) ([]byte, error) {
+	cfg := msg.dest.Email
+	title := fmt.Sprintf("🚨 Alert: %s", msg.sequencer)
+	color := "#c0392b"
+	if msg.resolved {
+		title = fmt.Sprintf("💜 Resolved: %s", msg.sequencer)
+		color = "#27ae60"
+	}
+
+	var html bytes.Buffer
+	err := emailHtml.Execute(&html, map[string]string{
+		"Title":     title,
+		"Color":     color,
+		"Sequencer": msg.sequencer,
+		"ChainId":   msg.chainId,
+		"Severity":  msg.severity,
+		"UniqueId":  msg.uniqueId,
+		"Message":   msg.message,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plain := fmt.Sprintf("%s\n\nSequencer: %s\nChain: %s\nSeverity: %s\nAlert id: %s\n\n%s\n",
+		title, msg.sequencer, msg.chainId, msg.severity, msg.uniqueId, msg.message)
+
+	subject := fmt.Sprintf("[Metisian] %s - %s", title, strings.SplitN(msg.message, "\n", 2)[0])
+	boundary := randomBoundary()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	fmt.Fprintf(&b, "--%s\r\n", boundary)
+	fmt.Fprintf(&b, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(plain, "\n", "\r\n"))
+	fmt.Fprintf(&b, "\r\n--%s\r\n", boundary)
+	fmt.Fprintf(&b, "Content-Type: text/html; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(html.String(), "\n", "\r\n"))
+	fmt.Fprintf(&b, "\r\n--%s--\r\n", boundary)
+
+	return b.Bytes(), nil
+}
+
+func randomBoundary() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return "metisian-" + hex.EncodeToString(buf)
+}
@@ -0,0 +1,83 @@
+package metis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(larkNotifier{})
+}
+
+type larkNotifier struct{}
+
+func (larkNotifier) Name() string {
+	return "lark"
+}
+
+func (larkNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Lark.Enabled
+}
+
+func (larkNotifier) checkConfig(d Destinations) []string {
+	return missing(map[string]string{"webhook": d.Lark.Webhook})
+}
+
+func (larkNotifier) Send(msg *alertMsg) (err error) {
+	data, err := json.Marshal(buildLarkMessage(msg))
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest("POST", msg.dest.Lark.Webhook, bytes.NewBuffer(data))
+	if err != nil {
+		return
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("could not notify lark for %s got %d response", msg.sequencer, resp.StatusCode)
+	}
+
+	return
+}
+
+// lark - sendMessage simple
+//
+//	{
+//		"msg_type": "text",
+//		"content": {
+//			"text": "'"$MESSAGE"'"
+//		}
+//	}
+type LarkMessage struct {
+	MsgType string      `json:"msg_type"`
+	Content LarkContent `json:"content"`
+}
+
+type LarkContent struct {
+	Text string `json:"text"`
+}
+
+func buildLarkMessage(msg *alertMsg) *LarkMessage {
+	prefix := ""
+	if msg.resolved {
+		msg.message = "OK: " + msg.message
+		prefix = "💜 Resolved: "
+	}
+	return &LarkMessage{
+		MsgType: "text",
+		Content: LarkContent{
+			Text: fmt.Sprintf("Metisian %s %s\n%s", prefix, msg.sequencer, msg.message),
+		},
+	}
+}
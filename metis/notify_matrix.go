@@ -0,0 +1,97 @@
+package metis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(matrixNotifier{})
+}
+
+type matrixNotifier struct{}
+
+func (matrixNotifier) Name() string {
+	return "matrix"
+}
+
+func (matrixNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Matrix.Enabled
+}
+
+func (matrixNotifier) checkConfig(d Destinations) []string {
+	return missing(map[string]string{"homeserver": d.Matrix.Homeserver, "access_token": d.Matrix.AccessToken, "room_id": d.Matrix.RoomId})
+}
+
+func (matrixNotifier) Send(msg *alertMsg) (err error) {
+	cfg := msg.dest.Matrix
+	data, err := json.Marshal(buildMatrixMessage(msg))
+	if err != nil {
+		return
+	}
+
+	// the homeserver drops a request reusing a transaction id, so it is kept across the retries of a delivery: a send
+	// timing out after the message was accepted isn't posted twice.
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimRight(cfg.Homeserver, "/"), url.PathEscape(cfg.RoomId), url.PathEscape(matrixTxnId(msg)))
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewBuffer(data))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+cfg.AccessToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("could not notify matrix for %s got %d response", msg.sequencer, resp.StatusCode)
+	}
+	return
+}
+
+// matrixTxnId identifies an alert's delivery, a message sent outside the queue gets a new id.
+func matrixTxnId(msg *alertMsg) string {
+	state := "firing"
+	if msg.resolved {
+		state = "resolved"
+	}
+	if msg.deliveryId == "" {
+		return fmt.Sprintf("metisian-%s-%s-%d", msg.uniqueId, state, time.Now().UnixNano())
+	}
+	return fmt.Sprintf("metisian-%s-%s-%s", msg.uniqueId, state, msg.deliveryId)
+}
+
+// MatrixMessage is a m.room.message event with an html formatted body
+type MatrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format"`
+	FormattedBody string `json:"formatted_body"`
+}
+
+func buildMatrixMessage(msg *alertMsg) *MatrixMessage {
+	prefix := "🚨 "
+	if msg.resolved {
+		msg.message = "OK: " + msg.message
+		prefix = "💜 Resolved: "
+	}
+	mentions := strings.Join(msg.dest.Matrix.Mentions, " ")
+	return &MatrixMessage{
+		MsgType: "m.text",
+		Body:    strings.TrimSpace(fmt.Sprintf("Metisian %s%s %s\n%s", prefix, msg.sequencer, mentions, msg.message)),
+		Format:  "org.matrix.custom.html",
+		FormattedBody: fmt.Sprintf("<b>Metisian %s%s</b> %s<br/><pre>%s</pre>",
+			html.EscapeString(prefix), html.EscapeString(msg.sequencer), html.EscapeString(mentions), html.EscapeString(msg.message)),
+	}
+}
@@ -0,0 +1,82 @@
+package metis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(mattermostNotifier{})
+}
+
+type mattermostNotifier struct{}
+
+func (mattermostNotifier) Name() string {
+	return "mattermost"
+}
+
+func (mattermostNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Mattermost.Enabled
+}
+
+func (mattermostNotifier) checkConfig(d Destinations) []string {
+	return missing(map[string]string{"webhook": d.Mattermost.Webhook})
+}
+
+func (mattermostNotifier) Send(msg *alertMsg) (err error) {
+	data, err := json.Marshal(buildMattermostMessage(msg))
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, msg.dest.Mattermost.Webhook, bytes.NewBuffer(data))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("could not notify mattermost for %s got %d response", msg.sequencer, resp.StatusCode)
+	}
+	return
+}
+
+// MattermostMessage is the payload of a Mattermost incoming webhook, attachments follow the slack format.
+type MattermostMessage struct {
+	Text        string       `json:"text"`
+	Username    string       `json:"username"`
+	Channel     string       `json:"channel,omitempty"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+func buildMattermostMessage(msg *alertMsg) *MattermostMessage {
+	prefix := ""
+	color := "#c0392b"
+	if msg.resolved {
+		msg.message = "OK: " + msg.message
+		prefix = "💜 Resolved: "
+		color = "#27ae60"
+	}
+	return &MattermostMessage{
+		Text:     msg.message,
+		Username: MetisianName,
+		Channel:  msg.dest.Mattermost.Channel,
+		Attachments: []Attachment{
+			{
+				Title: fmt.Sprintf("Metisian %s %s %s", prefix, msg.sequencer, strings.Join(msg.dest.Mattermost.Mentions, " ")),
+				Color: color,
+			},
+		},
+	}
+}
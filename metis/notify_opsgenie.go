@@ -0,0 +1,133 @@
+package metis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(opsgenieNotifier{})
+}
+
+const defaultOpsgenieUrl = "https://api.opsgenie.com"
+
+type opsgenieNotifier struct{}
+
+func (opsgenieNotifier) Name() string {
+	return "opsgenie"
+}
+
+func (opsgenieNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Opsgenie.Enabled
+}
+
+func (opsgenieNotifier) checkConfig(d Destinations) []string {
+	return missing(map[string]string{"api_key": d.Opsgenie.ApiKey})
+}
+
+// OpsgenieAlert is the body used to create an alert, see https://docs.opsgenie.com/docs/alert-api#create-alert
+type OpsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source"`
+	Tags        []string          `json:"tags,omitempty"`
+	Details     map[string]string `json:"details"`
+}
+
+// OpsgenieClose is the body used to close an alert by its alias
+type OpsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+// Send creates an alert using the uniqueId as alias, and closes the alert with the same alias once resolved.
+func (opsgenieNotifier) Send(msg *alertMsg) error {
+	cfg := msg.dest.Opsgenie
+	apiUrl := strings.TrimRight(cfg.ApiUrl, "/")
+	if apiUrl == "" {
+		apiUrl = defaultOpsgenieUrl
+	}
+
+	var (
+		endpoint string
+		body     interface{}
+	)
+	if msg.resolved {
+		endpoint = fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", apiUrl, url.PathEscape(msg.uniqueId))
+		body = OpsgenieClose{
+			Source: MetisianName,
+			Note:   "Resolved: " + msg.message,
+		}
+	} else {
+		endpoint = apiUrl + "/v2/alerts"
+		body = buildOpsgenieAlert(msg)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+cfg.ApiKey)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("could not notify opsgenie for %s got %d response", msg.sequencer, resp.StatusCode)
+	}
+	return nil
+}
+
+func buildOpsgenieAlert(msg *alertMsg) *OpsgenieAlert {
+	// opsgenie truncates the message at 130 characters, keep the sequencer in front so it's always visible.
+	message := fmt.Sprintf("%s: %s", msg.sequencer, strings.SplitN(msg.message, "\n", 2)[0])
+	if r := []rune(message); len(r) > 130 {
+		message = string(r[:130])
+	}
+	return &OpsgenieAlert{
+		Message:     message,
+		Alias:       msg.uniqueId,
+		Description: msg.message,
+		Priority:    opsgeniePriority(msg.severity),
+		Source:      MetisianName,
+		Tags:        msg.dest.Opsgenie.Tags,
+		Details: map[string]string{
+			"sequencer": msg.sequencer,
+			"address":   msg.address,
+			"chain_id":  msg.chainId,
+			"severity":  msg.severity,
+		},
+	}
+}
+
+// opsgeniePriority maps the pagerduty style severities used in the config to opsgenie priorities.
+func opsgeniePriority(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return "P1"
+	case "error":
+		return "P2"
+	case "warning", "warn":
+		return "P3"
+	case "info":
+		return "P5"
+	default:
+		return "P3"
+	}
+}
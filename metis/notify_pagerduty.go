@@ -0,0 +1,55 @@
+package metis
+
+import (
+	"context"
+	"errors"
+	"github.com/PagerDuty/go-pagerduty"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(pagerdutyNotifier{})
+}
+
+type pagerdutyNotifier struct{}
+
+func (pagerdutyNotifier) Name() string {
+	return "pagerduty"
+}
+
+func (pagerdutyNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Pagerduty.Enabled
+}
+
+func (pagerdutyNotifier) checkConfig(d Destinations) []string {
+	return missing(map[string]string{"api_key": d.Pagerduty.ApiKey})
+}
+
+// suppressFlapping enables the basic flap detection of shouldNotify for pagerduty.
+func (pagerdutyNotifier) suppressFlapping() bool {
+	return true
+}
+
+func (pagerdutyNotifier) Send(msg *alertMsg) error {
+	// key from the example, don't spam their api
+	if msg.dest.Pagerduty.ApiKey == "aaaaaaaaaaaabbbbbbbbbbbbbcccccccccccc" {
+		return errors.New("invalid pagerduty key")
+	}
+	action := "trigger"
+	if msg.resolved {
+		action = "resolve"
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err := pagerduty.ManageEventWithContext(ctx, pagerduty.V2Event{
+		RoutingKey: msg.dest.Pagerduty.ApiKey,
+		Action:     action,
+		DedupKey:   msg.uniqueId,
+		Payload: &pagerduty.V2Payload{
+			Summary:  msg.message,
+			Source:   msg.uniqueId,
+			Severity: msg.severity,
+		},
+	})
+	return err
+}
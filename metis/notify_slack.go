@@ -0,0 +1,84 @@
+package metis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(slackNotifier{})
+}
+
+type slackNotifier struct{}
+
+func (slackNotifier) Name() string {
+	return "slack"
+}
+
+func (slackNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Slack.Enabled
+}
+
+func (slackNotifier) checkConfig(d Destinations) []string {
+	return missing(map[string]string{"webhook": d.Slack.Webhook})
+}
+
+func (slackNotifier) Send(msg *alertMsg) (err error) {
+	data, err := json.Marshal(buildSlackMessage(msg))
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest("POST", msg.dest.Slack.Webhook, bytes.NewBuffer(data))
+	if err != nil {
+		return
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("could not notify slack for %s got %d response", msg.sequencer, resp.StatusCode)
+	}
+
+	return
+}
+
+type SlackMessage struct {
+	Text        string       `json:"text"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+type Attachment struct {
+	Text      string `json:"text"`
+	Color     string `json:"color"`
+	Title     string `json:"title"`
+	TitleLink string `json:"title_link"`
+}
+
+func buildSlackMessage(msg *alertMsg) *SlackMessage {
+	prefix := ""
+	color := "danger"
+	if msg.resolved {
+		msg.message = "OK: " + msg.message
+		prefix = "💜 Resolved: "
+		color = "good"
+	}
+	return &SlackMessage{
+		Text: msg.message,
+		Attachments: []Attachment{
+			{
+				Title: fmt.Sprintf("Metisian %s %s %s", prefix, msg.sequencer, strings.Join(msg.dest.Slack.Mentions, " ")),
+				Color: color,
+			},
+		},
+	}
+}
@@ -0,0 +1,127 @@
+package metis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(teamsNotifier{})
+}
+
+type teamsNotifier struct{}
+
+func (teamsNotifier) Name() string {
+	return "teams"
+}
+
+func (teamsNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Teams.Enabled
+}
+
+func (teamsNotifier) checkConfig(d Destinations) []string {
+	return missing(map[string]string{"webhook": d.Teams.Webhook})
+}
+
+func (teamsNotifier) Send(msg *alertMsg) (err error) {
+	data, err := json.Marshal(buildTeamsMessage(msg))
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, msg.dest.Teams.Webhook, bytes.NewBuffer(data))
+	if err != nil {
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	_ = resp.Body.Close()
+
+	// incoming webhooks answer 200, workflow (power automate) webhooks answer 202.
+	if resp.StatusCode != 200 && resp.StatusCode != 202 {
+		return fmt.Errorf("could not notify teams for %s got %d response", msg.sequencer, resp.StatusCode)
+	}
+	return
+}
+
+// TeamsMessage wraps an adaptive card, see https://learn.microsoft.com/en-us/microsoftteams/platform/webhooks-and-connectors/how-to/connectors-using
+type TeamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []TeamsAttachment `json:"attachments"`
+}
+
+type TeamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     AdaptiveCard `json:"content"`
+}
+
+type AdaptiveCard struct {
+	Schema  string              `json:"$schema"`
+	Type    string              `json:"type"`
+	Version string              `json:"version"`
+	Body    []AdaptiveTextBlock `json:"body"`
+}
+
+type AdaptiveTextBlock struct {
+	Type   string `json:"type"`
+	Text   string `json:"text"`
+	Weight string `json:"weight,omitempty"`
+	Size   string `json:"size,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Wrap   bool   `json:"wrap"`
+}
+
+func buildTeamsMessage(msg *alertMsg) *TeamsMessage {
+	prefix := "🚨 "
+	color := "attention"
+	if msg.resolved {
+		msg.message = "OK: " + msg.message
+		prefix = "💜 Resolved: "
+		color = "good"
+	}
+	body := []AdaptiveTextBlock{
+		{
+			Type:   "TextBlock",
+			Text:   fmt.Sprintf("Metisian %s%s", prefix, msg.sequencer),
+			Weight: "bolder",
+			Size:   "medium",
+			Color:  color,
+			Wrap:   true,
+		},
+		{
+			Type: "TextBlock",
+			Text: msg.message,
+			Wrap: true,
+		},
+	}
+	if len(msg.dest.Teams.Mentions) > 0 {
+		body = append(body, AdaptiveTextBlock{
+			Type: "TextBlock",
+			Text: strings.Join(msg.dest.Teams.Mentions, " "),
+			Wrap: true,
+		})
+	}
+	return &TeamsMessage{
+		Type: "message",
+		Attachments: []TeamsAttachment{
+			{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content: AdaptiveCard{
+					Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+					Type:    "AdaptiveCard",
+					Version: "1.4",
+					Body:    body,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package metis
+
+import (
+	"fmt"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"net/http"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(telegramNotifier{})
+}
+
+type telegramNotifier struct{}
+
+func (telegramNotifier) Name() string {
+	return "telegram"
+}
+
+func (telegramNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Telegram.Enabled
+}
+
+func (telegramNotifier) checkConfig(d Destinations) []string {
+	return missing(map[string]string{"api_key": d.Telegram.ApiKey, "channel": d.Telegram.Channel})
+}
+
+func (telegramNotifier) Send(msg *alertMsg) error {
+	bot, err := getTelegramBot(msg.dest.Telegram.ApiKey)
+	if err != nil {
+		return fmt.Errorf("notify telegram: %w", err)
+	}
+
+	prefix := ""
+	if msg.resolved {
+		prefix = "💜 Resolved: "
+	}
+
+	mc := tgbotapi.NewMessageToChannel(msg.dest.Telegram.Channel, fmt.Sprintf("%s: %s - %s", msg.sequencer, prefix, msg.message))
+	_, err = bot.Send(mc)
+	if err != nil {
+		return fmt.Errorf("telegram send: %w", err)
+	}
+	return nil
+}
+
+var (
+	telegramBots   = make(map[string]*tgbotapi.BotAPI)
+	telegramBotMux sync.Mutex
+)
+
+// getTelegramBot returns a bot per api key, creating a bot calls getMe so they are reused for every message.
+func getTelegramBot(apiKey string) (*tgbotapi.BotAPI, error) {
+	telegramBotMux.Lock()
+	defer telegramBotMux.Unlock()
+	if bot := telegramBots[apiKey]; bot != nil {
+		return bot, nil
+	}
+	bot, err := tgbotapi.NewBotAPIWithClient(apiKey, tgbotapi.APIEndpoint, &http.Client{Timeout: 30 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	telegramBots[apiKey] = bot
+	return bot, nil
+}
@@ -0,0 +1,145 @@
+package metis
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+func init() {
+	RegisterNotifier(webhookNotifier{})
+}
+
+const (
+	defaultWebhookTemplate = `{"sequencer":{{json .Sequencer}},"message":{{json .Message}},"severity":{{json .Severity}},` +
+		`"resolved":{{json .Resolved}},"uniqueId":{{json .UniqueId}},"chainId":{{json .ChainId}}}`
+	defaultWebhookContentType     = "application/json"
+	defaultWebhookSignatureHeader = "X-Metisian-Signature"
+)
+
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// WebhookPayload is the data the webhook template is rendered with.
+type WebhookPayload struct {
+	Sequencer string
+	Message   string
+	Severity  string
+	Resolved  bool
+	UniqueId  string
+	ChainId   string
+}
+
+type webhookNotifier struct{}
+
+func (webhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (webhookNotifier) Enabled(msg *alertMsg) bool {
+	return msg.dest.Webhook.Enabled
+}
+
+func (webhookNotifier) checkConfig(d Destinations) []string {
+	problems := missing(map[string]string{"url": d.Webhook.Url})
+	if d.Webhook.Template != "" {
+		if _, err := template.New("webhook").Funcs(webhookFuncs).Parse(d.Webhook.Template); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid template: %v", err))
+		}
+	}
+	return problems
+}
+
+func (webhookNotifier) Send(msg *alertMsg) error {
+	cfg := msg.dest.Webhook
+	body, err := buildWebhookBody(msg)
+	if err != nil {
+		return err
+	}
+
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, cfg.Url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+
+	contentType := cfg.ContentType
+	if contentType == "" {
+		contentType = defaultWebhookContentType
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if cfg.Secret != "" {
+		header := cfg.SignatureHeader
+		if header == "" {
+			header = defaultWebhookSignatureHeader
+		}
+		req.Header.Set(header, "sha256="+signWebhookBody(cfg.Secret, body))
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("could not notify webhook for %s got %d response", msg.sequencer, resp.StatusCode)
+	}
+	return nil
+}
+
+// buildWebhookBody renders the configured template, json bodies are checked so a broken template is reported here
+// instead of by the receiver.
+func buildWebhookBody(msg *alertMsg) ([]byte, error) {
+	cfg := msg.dest.Webhook
+	text := cfg.Template
+	if text == "" {
+		text = defaultWebhookTemplate
+	}
+	tmpl, err := template.New("webhook").Funcs(webhookFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, WebhookPayload{
+		Sequencer: msg.sequencer,
+		Message:   msg.message,
+		Severity:  msg.severity,
+		Resolved:  msg.resolved,
+		UniqueId:  msg.uniqueId,
+		ChainId:   msg.chainId,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("rendering webhook template: %w", err)
+	}
+
+	if (cfg.ContentType == "" || strings.Contains(cfg.ContentType, "json")) && !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("webhook template did not render valid json: %s", buf.String())
+	}
+	return buf.Bytes(), nil
+}
+
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
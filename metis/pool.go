@@ -0,0 +1,184 @@
+package metis
+
+import (
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// NodeScore is the standing of a node in the endpoint pool. Nodes score from 0 to 100: a node which is down, catching
+// up or forked from its peers scores 0, others lose points for latency, for lagging behind the highest node and for
+// failed probes.
+type NodeScore struct {
+	RpcURL    string    `json:"rpc_url"`
+	Score     float64   `json:"score"`
+	Active    bool      `json:"active"`
+	Down      bool      `json:"down"`
+	Syncing   bool      `json:"catching_up"`
+	Forked    bool      `json:"forked"`
+	Height    int64     `json:"height"`
+	Lag       int64     `json:"lag"`
+	LatencyMs int64     `json:"latency_ms"`
+	ErrorRate float64   `json:"error_rate"`
+	LastError string    `json:"last_error,omitempty"`
+	LastCheck time.Time `json:"last_check"`
+}
+
+const (
+	maxLatencyPenalty = 25.0
+	maxLagPenalty     = 40.0
+	maxErrorPenalty   = 35.0
+)
+
+// nodeScores scores every node, in the order of the config.
+func (c *MetisianClient) nodeScores() []NodeScore {
+	return c.scoreNodes(c.nodes())
+}
+
+func (c *MetisianClient) scoreNodes(nodes []NodeInfo) []NodeScore {
+	states := make([]nodeState, len(nodes))
+	var top int64
+	for i, node := range nodes {
+		states[i] = node.health.snapshot()
+		if !states[i].down && states[i].height > top {
+			top = states[i].height
+		}
+	}
+
+	active := ""
+	if mc := c.rpcClient(); mc != nil {
+		active = mc.rpcUrl
+	}
+
+	scores := make([]NodeScore, len(nodes))
+	for i, node := range nodes {
+		st := states[i]
+		ns := NodeScore{
+			RpcURL:    node.RpcURL,
+			Active:    node.RpcURL == active,
+			Down:      st.down,
+			Syncing:   st.syncing,
+			Forked:    st.forked,
+			Height:    st.height,
+			LatencyMs: st.latency.Milliseconds(),
+			ErrorRate: st.errorRate(),
+			LastError: st.lastMsg,
+			LastCheck: st.lastCheck,
+		}
+		if st.height > 0 && top > st.height {
+			ns.Lag = top - st.height
+		}
+		if !st.down && !st.forked && !st.lastCheck.IsZero() {
+			ns.Score = 100 -
+				minFloat(float64(ns.LatencyMs)/20, maxLatencyPenalty) -
+				minFloat(float64(ns.Lag)*5, maxLagPenalty) -
+				ns.ErrorRate*maxErrorPenalty
+			if ns.Score < 0 {
+				ns.Score = 0
+			}
+		}
+		scores[i] = ns
+	}
+	return scores
+}
+
+// rankedNodes returns the nodes to try for the websocket subscription, the preferred node first and then the others
+// by score. Nodes with the same score keep the order of the config.
+func (c *MetisianClient) rankedNodes() []NodeInfo {
+	nodes := c.nodes()
+	scores := c.scoreNodes(nodes)
+	order := make([]int, len(nodes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		if pa, pb := nodes[order[a]].RpcURL == c.preferredRpc, nodes[order[b]].RpcURL == c.preferredRpc; pa != pb {
+			return pa
+		}
+		return scores[order[a]].Score > scores[order[b]].Score
+	})
+	ranked := make([]NodeInfo, len(order))
+	for i, o := range order {
+		ranked[i] = nodes[o]
+	}
+	return ranked
+}
+
+// checkFailover moves the websocket subscription to the best scored node. It fails over at once when the active node
+// is down, catching up or lags more than max_lag_blocks. While the active node is healthy it only moves to a node
+// scoring switch_margin points more, and not sooner than min_switch_minutes after the previous switch, so that two
+// close nodes don't take turns.
+func (c *MetisianClient) checkFailover() {
+	if c.client == nil {
+		return
+	}
+	scores := c.nodeScores()
+	var active, best *NodeScore
+	for i := range scores {
+		if scores[i].Active {
+			active = &scores[i]
+		}
+		if best == nil || scores[i].Score > best.Score {
+			best = &scores[i]
+		}
+	}
+	if active == nil || best == nil || best.Active || best.Score == 0 {
+		return
+	}
+
+	pool := c.thresholds().pool
+	var reason string
+	switch {
+	case active.Down || active.Syncing:
+		reason = "active node is unhealthy"
+	case active.Forked:
+		reason = "active node disagrees with its peers on block hashes"
+	case active.Lag > int64(pool.MaxLagBlocks):
+		reason = fmt.Sprintf("active node lags %d blocks behind", active.Lag)
+	case best.Score >= active.Score+pool.SwitchMargin &&
+		time.Since(c.lastSwitch) >= time.Duration(pool.MinSwitchMinutes)*time.Minute:
+		reason = fmt.Sprintf("scores %.0f against %.0f", best.Score, active.Score)
+	default:
+		return
+	}
+
+	log.Warn(fmt.Sprintf("🔀 moving websocket from %s to %s: %s", active.RpcURL, best.RpcURL, reason))
+	c.preferredRpc = best.RpcURL
+	c.lastSwitch = time.Now()
+	// closing the connection ends WsRun, the Run loop then reconnects to the preferred node.
+	_ = c.client.wsConn.Close()
+}
+
+// nodesHandler serves the scores of the endpoint pool on the dashboard.
+func (c *MetisianClient) nodesHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		if request.Method != http.MethodGet {
+			writeJsonError(writer, http.StatusMethodNotAllowed, request.Method+" is not supported on "+request.URL.Path)
+			return
+		}
+		writeJson(writer, http.StatusOK, c.publicNodeScores())
+	})
+}
+
+// publicNodeScores are the scores served to the dashboard and the apis, without the node urls when the logs are
+// hidden as they may contain api keys.
+func (c *MetisianClient) publicNodeScores() []NodeScore {
+	scores := c.nodeScores()
+	if c.HideLogs {
+		for i := range scores {
+			scores[i].RpcURL = fmt.Sprintf("node %d", i+1)
+			scores[i].LastError = ""
+		}
+	}
+	return scores
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,422 @@
+package metis
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultQueueFile      = ".metisian-queue.json"
+	DefaultDeadLetterFile = ".metisian-dead-letter.jsonl"
+)
+
+// Delivery is a notification for a single destination. Pending deliveries are kept in the queue file, the ones
+// which couldn't be delivered are appended to the dead-letter file. Destination settings are never written to disk,
+// they are read from the config when the delivery is sent.
+type Delivery struct {
+	Id        string    `json:"id"`
+	Notifier  string    `json:"notifier"`
+	Severity  string    `json:"severity"`
+	Resolved  bool      `json:"resolved"`
+	Sequencer string    `json:"sequencer"`
+	Address   string    `json:"address"`
+	Message   string    `json:"message"`
+	UniqueId  string    `json:"unique_id"`
+	ChainId   string    `json:"chain_id"`
+	Renotify  bool      `json:"renotify,omitempty"`
+	Created   time.Time `json:"created"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+
+	msg      *alertMsg
+	inFlight bool
+}
+
+func newDelivery(msg *alertMsg, notifier string) *Delivery {
+	return &Delivery{
+		Id:        newSilenceId(),
+		Notifier:  notifier,
+		Severity:  msg.severity,
+		Resolved:  msg.resolved,
+		Sequencer: msg.sequencer,
+		Address:   msg.address,
+		Message:   msg.message,
+		UniqueId:  msg.uniqueId,
+		ChainId:   msg.chainId,
+		Renotify:  msg.renotify,
+		Created:   time.Now(),
+		msg:       msg,
+	}
+}
+
+func (d *Delivery) key() string {
+	return d.Sequencer + d.Message
+}
+
+// alertMsg returns a copy of the alert to send, deliveries loaded from disk get the destinations of the config.
+func (d *Delivery) alertMsg(destinations func(seqName string) Destinations) *alertMsg {
+	if d.msg != nil {
+		m := *d.msg
+		m.deliveryId = d.Id
+		return &m
+	}
+	return &alertMsg{
+		severity:   d.Severity,
+		resolved:   d.Resolved,
+		sequencer:  d.Sequencer,
+		address:    d.Address,
+		message:    d.Message,
+		uniqueId:   d.UniqueId,
+		chainId:    d.ChainId,
+		renotify:   d.Renotify,
+		deliveryId: d.Id,
+		dest:       destinations(d.Sequencer),
+	}
+}
+
+// deliveryQueue delivers notifications. Every destination has a worker sending its notifications in order, when a
+// send fails the whole destination backs off exponentially so an outage doesn't reorder alarms and resolutions. A
+// notification still failing after max_attempts goes to the dead-letter file. The queue is written to disk on every
+// change so pending notifications survive a restart.
+type deliveryQueue struct {
+	cfg          RetryConfig
+	alarms       *alarmCache
+	destinations func(seqName string) Destinations
+
+	mux     sync.Mutex
+	pending map[string][]*Delivery
+	wake    map[string]chan struct{}
+
+	fileMux sync.Mutex
+}
+
+func newDeliveryQueue(cfg RetryConfig, alarms *alarmCache, destinations func(seqName string) Destinations) *deliveryQueue {
+	q := &deliveryQueue{
+		cfg:          cfg,
+		alarms:       alarms,
+		destinations: destinations,
+		pending:      make(map[string][]*Delivery),
+		wake:         make(map[string]chan struct{}),
+	}
+	for _, n := range registeredNotifiers() {
+		q.wake[n.Name()] = make(chan struct{}, 1)
+	}
+
+	b, err := os.ReadFile(cfg.QueueFile)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warn(fmt.Sprintf("could not read notification queue %s: %v", cfg.QueueFile, err))
+		}
+		return q
+	}
+	saved := make([]*Delivery, 0)
+	if err = json.Unmarshal(b, &saved); err != nil {
+		log.Warn(fmt.Sprintf("could not parse notification queue %s: %v", cfg.QueueFile, err))
+		return q
+	}
+	for _, d := range saved {
+		if q.wake[d.Notifier] == nil {
+			log.Warn(fmt.Sprintf("dropping queued notification %s for unknown destination %s", d.Id, d.Notifier))
+			continue
+		}
+		q.pending[d.Notifier] = append(q.pending[d.Notifier], d)
+	}
+	if len(saved) > 0 {
+		log.Info(fmt.Sprintf("loaded %d pending notifications from %s", len(saved), cfg.QueueFile))
+	}
+	return q
+}
+
+// notify queues the alert for every registered destination enabled for it.
+func (q *deliveryQueue) notify(msg *alertMsg) {
+	for _, n := range registeredNotifiers() {
+		name := n.Name()
+		if !msg.sendsTo(name) || !n.Enabled(msg) {
+			continue
+		}
+		if msg.resolved {
+			cancelled, inFlight := q.cancelAlarm(name, msg.sequencer+msg.message)
+			if inFlight {
+				// the alarm is being sent right now, the resolution has to follow it.
+				q.push(newDelivery(msg, name))
+				continue
+			}
+			if cancelled && !q.alarms.wasSent(name, msg.sequencer+msg.message) {
+				msg.logger().With(log.Fields{"destination": name}).Info("alarm was resolved before it was delivered: " + msg.message)
+				continue
+			}
+		}
+		if !msg.resolved && !msg.renotify && q.hasAlarm(name, msg.sequencer+msg.message) {
+			// already waiting for delivery
+			continue
+		}
+		if !q.alarms.shouldNotify(msg, n) {
+			continue
+		}
+		q.push(newDelivery(msg, name))
+	}
+}
+
+func (q *deliveryQueue) hasAlarm(notifier, key string) bool {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	for _, d := range q.pending[notifier] {
+		if !d.Resolved && d.key() == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *deliveryQueue) push(d *Delivery) {
+	q.mux.Lock()
+	q.pending[d.Notifier] = append(q.pending[d.Notifier], d)
+	q.mux.Unlock()
+	q.save()
+	select {
+	case q.wake[d.Notifier] <- struct{}{}:
+	default:
+	}
+}
+
+// cancelAlarm drops the queued alarms with the key, and reports whether one of them is being sent.
+func (q *deliveryQueue) cancelAlarm(notifier, key string) (cancelled, inFlight bool) {
+	q.mux.Lock()
+	kept := q.pending[notifier][:0]
+	for _, d := range q.pending[notifier] {
+		switch {
+		case d.Resolved || d.key() != key:
+			kept = append(kept, d)
+		case d.inFlight:
+			inFlight = true
+			kept = append(kept, d)
+		default:
+			cancelled = true
+		}
+	}
+	q.pending[notifier] = kept
+	q.mux.Unlock()
+	if cancelled {
+		q.save()
+	}
+	return
+}
+
+func (q *deliveryQueue) head(notifier string) *Delivery {
+	q.mux.Lock()
+	defer q.mux.Unlock()
+	if len(q.pending[notifier]) == 0 {
+		return nil
+	}
+	d := q.pending[notifier][0]
+	d.inFlight = true
+	return d
+}
+
+func (q *deliveryQueue) remove(d *Delivery) {
+	q.mux.Lock()
+	for i, p := range q.pending[d.Notifier] {
+		if p == d {
+			q.pending[d.Notifier] = append(q.pending[d.Notifier][:i], q.pending[d.Notifier][i+1:]...)
+			break
+		}
+	}
+	q.mux.Unlock()
+	q.save()
+}
+
+func (q *deliveryQueue) backoff(failures int) time.Duration {
+	b := time.Duration(q.cfg.InitialBackoffSeconds) * time.Second
+	max := time.Duration(q.cfg.MaxBackoffSeconds) * time.Second
+	for i := 1; i < failures && b < max; i++ {
+		b *= 2
+	}
+	if b > max {
+		b = max
+	}
+	return b
+}
+
+// start runs a worker for every destination.
+func (q *deliveryQueue) start(ctx context.Context) {
+	for _, n := range registeredNotifiers() {
+		go q.work(ctx, n)
+	}
+}
+
+func (q *deliveryQueue) work(ctx context.Context, n Notifier) {
+	name := n.Name()
+	failures := 0
+	for {
+		d := q.head(name)
+		if d == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake[name]:
+			}
+			continue
+		}
+
+		err := n.Send(d.alertMsg(q.destinations))
+		q.mux.Lock()
+		d.inFlight = false
+		if err != nil {
+			d.Attempts++
+			d.LastError = err.Error()
+		}
+		q.mux.Unlock()
+
+		if err == nil {
+			failures = 0
+			q.alarms.markDelivered(name, d.key(), d.Resolved)
+			q.remove(d)
+			continue
+		}
+
+		failures++
+		wait := q.backoff(failures)
+		if d.Attempts >= q.cfg.MaxAttempts {
+			log.ErrorDynamicArgs(d.Sequencer, fmt.Sprintf("giving up on %s after %d attempts, moved to %s", name, d.Attempts, q.cfg.DeadLetterFile), err.Error())
+			q.deadLetter(d)
+			q.remove(d)
+		} else {
+			log.With(log.Fields{"sequencer": d.Sequencer, "alert_id": d.UniqueId, "destination": name}).
+				Warn(fmt.Sprintf("error sending alert (attempt %d/%d), retrying in %s: %v", d.Attempts, q.cfg.MaxAttempts, wait, err))
+			q.save()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// save writes the pending deliveries to the queue file.
+func (q *deliveryQueue) save() {
+	q.mux.Lock()
+	all := make([]*Delivery, 0)
+	for _, pending := range q.pending {
+		all = append(all, pending...)
+	}
+	b, err := json.Marshal(all)
+	q.mux.Unlock()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	q.fileMux.Lock()
+	defer q.fileMux.Unlock()
+	tmp := q.cfg.QueueFile + ".tmp"
+	if err = os.WriteFile(tmp, b, 0600); err == nil {
+		err = os.Rename(tmp, q.cfg.QueueFile)
+	}
+	if err != nil {
+		log.Warn(fmt.Sprintf("could not save notification queue: %v", err))
+	}
+}
+
+func (q *deliveryQueue) deadLetter(d *Delivery) {
+	q.fileMux.Lock()
+	defer q.fileMux.Unlock()
+	//#nosec -- variable specified in the config file
+	f, err := os.OpenFile(q.cfg.DeadLetterFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	defer f.Close()
+	b, _ := json.Marshal(d)
+	if _, err = f.Write(append(b, '\n')); err != nil {
+		log.Error(err)
+	}
+}
+
+// replay queues the dead letters with the given ids again, or all of them when ids is empty. Alarms which have been
+// resolved in the meantime, and resolutions of alarms the destination never received, are dropped. The remaining
+// dead letters are kept in the file.
+func (q *deliveryQueue) replay(ids []string) (queued, dropped []*Delivery, err error) {
+	q.fileMux.Lock()
+	letters, err := ReadDeadLetters(q.cfg.DeadLetterFile)
+	if err != nil {
+		q.fileMux.Unlock()
+		return nil, nil, err
+	}
+	kept := make([]*Delivery, 0)
+	for _, d := range letters {
+		switch {
+		case len(ids) > 0 && !contains(ids, d.Id):
+			kept = append(kept, d)
+		case q.wake[d.Notifier] == nil,
+			!d.Resolved && !q.alarms.isActive(d.Sequencer, d.Message),
+			d.Resolved && !q.alarms.wasSent(d.Notifier, d.key()):
+			dropped = append(dropped, d)
+		default:
+			d.Attempts = 0
+			d.LastError = ""
+			queued = append(queued, d)
+		}
+	}
+	err = writeDeadLetters(q.cfg.DeadLetterFile, kept)
+	q.fileMux.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, d := range queued {
+		q.push(d)
+	}
+	return queued, dropped, nil
+}
+
+// ReadDeadLetters reads a dead-letter file, a missing file has no dead letters.
+func ReadDeadLetters(path string) ([]*Delivery, error) {
+	//#nosec -- variable specified on command line
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []*Delivery{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	letters := make([]*Delivery, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		d := &Delivery{}
+		if err = json.Unmarshal(scanner.Bytes(), d); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		letters = append(letters, d)
+	}
+	return letters, scanner.Err()
+}
+
+func writeDeadLetters(path string, letters []*Delivery) error {
+	b := make([]byte, 0)
+	for _, d := range letters {
+		j, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		b = append(append(b, j...), '\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
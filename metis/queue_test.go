@@ -0,0 +1,176 @@
+package metis
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// failingNotifier fails every send, and counts them.
+type failingNotifier struct {
+	mux   sync.Mutex
+	sends int
+}
+
+func (*failingNotifier) Name() string           { return "failing" }
+func (*failingNotifier) Enabled(*alertMsg) bool { return true }
+func (f *failingNotifier) Send(*alertMsg) error {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.sends++
+	return errors.New("destination is down")
+}
+
+func testQueue(t *testing.T, cfg RetryConfig, alarms *alarmCache) *deliveryQueue {
+	t.Helper()
+	dir := t.TempDir()
+	cfg.QueueFile = filepath.Join(dir, "queue.json")
+	cfg.DeadLetterFile = filepath.Join(dir, "dead-letter.jsonl")
+	q := newDeliveryQueue(cfg, alarms, func(string) Destinations { return Destinations{} })
+	q.wake["failing"] = make(chan struct{}, 1)
+	return q
+}
+
+func TestDeliveryQueueBackoff(t *testing.T) {
+	q := testQueue(t, RetryConfig{InitialBackoffSeconds: 2, MaxBackoffSeconds: 60}, newAlarmCache())
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 2 * time.Second},
+		{2, 4 * time.Second},
+		{3, 8 * time.Second},
+		{5, 32 * time.Second},
+		{6, 60 * time.Second},
+		{100, 60 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := q.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestDeliveryQueueDeadLetterAfterMaxAttempts(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+	}{
+		{"single attempt", 1},
+		{"several attempts", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := testQueue(t, RetryConfig{MaxAttempts: tt.maxAttempts}, newAlarmCache())
+			n := &failingNotifier{}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go q.work(ctx, n)
+
+			q.push(newDelivery(&alertMsg{sequencer: "seq", message: "missed blocks", severity: "warning"}, n.Name()))
+
+			deadline := time.Now().Add(5 * time.Second)
+			var letters []*Delivery
+			for len(letters) == 0 && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+				var err error
+				if letters, err = ReadDeadLetters(q.cfg.DeadLetterFile); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(letters) != 1 {
+				t.Fatalf("got %d dead letters, want 1", len(letters))
+			}
+			if letters[0].Attempts != tt.maxAttempts || letters[0].LastError != "destination is down" {
+				t.Errorf("dead letter has %d attempts and error %q", letters[0].Attempts, letters[0].LastError)
+			}
+			if q.head(n.Name()) != nil {
+				t.Error("the dead letter is still queued")
+			}
+			n.mux.Lock()
+			defer n.mux.Unlock()
+			if n.sends != tt.maxAttempts {
+				t.Errorf("sent %d times, want %d", n.sends, tt.maxAttempts)
+			}
+		})
+	}
+}
+
+func TestDeliveryQueueReplay(t *testing.T) {
+	letter := func(id, notifier, message string, resolved bool) *Delivery {
+		return &Delivery{Id: id, Notifier: notifier, Sequencer: "seq", Message: message, Resolved: resolved, Attempts: 5, LastError: "timeout"}
+	}
+	letters := []*Delivery{
+		letter("active", "failing", "still firing", false),
+		letter("cleared", "failing", "cleared meanwhile", false),
+		letter("resolution", "failing", "delivered alarm", true),
+		letter("orphan", "failing", "never delivered", true),
+		letter("unknown", "gone", "still firing", false),
+	}
+	tests := []struct {
+		name    string
+		ids     []string
+		queued  []string
+		dropped []string
+		kept    []string
+	}{
+		{"all", nil, []string{"active", "resolution"}, []string{"cleared", "orphan", "unknown"}, nil},
+		{"by id", []string{"active", "orphan"}, []string{"active"}, []string{"orphan"}, []string{"cleared", "resolution", "unknown"}},
+		{"unknown id", []string{"nope"}, nil, nil, []string{"active", "cleared", "resolution", "orphan", "unknown"}},
+	}
+	ids := func(ds []*Delivery) []string {
+		result := make([]string, 0, len(ds))
+		for _, d := range ds {
+			result = append(result, d.Id)
+		}
+		sort.Strings(result)
+		return result
+	}
+	sorted := func(s []string) []string {
+		result := append([]string{}, s...)
+		sort.Strings(result)
+		return result
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alarms := newAlarmCache()
+			alarms.AllAlarms["seq"] = map[string]time.Time{"still firing": time.Now()}
+			alarms.sentAlarms("failing")["seqdelivered alarm"] = time.Now()
+			q := testQueue(t, RetryConfig{MaxAttempts: 3}, alarms)
+			if err := writeDeadLetters(q.cfg.DeadLetterFile, letters); err != nil {
+				t.Fatal(err)
+			}
+
+			queued, dropped, err := q.replay(tt.ids)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(queued); !slices.Equal(got, sorted(tt.queued)) {
+				t.Errorf("queued %v, want %v", got, tt.queued)
+			}
+			if got := ids(dropped); !slices.Equal(got, sorted(tt.dropped)) {
+				t.Errorf("dropped %v, want %v", got, tt.dropped)
+			}
+			for _, d := range queued {
+				if d.Attempts != 0 || d.LastError != "" {
+					t.Errorf("%s was queued again with %d attempts and error %q", d.Id, d.Attempts, d.LastError)
+				}
+			}
+			if got := ids(q.pending["failing"]); !slices.Equal(got, sorted(tt.queued)) {
+				t.Errorf("pending %v, want %v", got, tt.queued)
+			}
+			remaining, err := ReadDeadLetters(q.cfg.DeadLetterFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(remaining); !slices.Equal(got, sorted(tt.kept)) {
+				t.Errorf("kept %v, want %v", got, tt.kept)
+			}
+		})
+	}
+}
@@ -0,0 +1,327 @@
+package metis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	dash "github.com/b-harvest/metisian/metis/dashboard"
+	"github.com/fsnotify/fsnotify"
+	"github.com/metis-seq/themis/types"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// WatchConfig reloads the configuration on SIGHUP and whenever the file changes. A remote configuration is fetched
+// again every interval instead, zero only reloads it on SIGHUP. A configuration which can't be loaded or isn't valid
+// is logged and the running one is kept.
+func (s *Supervisor) WatchConfig(ctx context.Context, filePath, token string, interval time.Duration) {
+	reload := make(chan struct{}, 1)
+	trigger := func() {
+		select {
+		case reload <- struct{}{}:
+		default:
+		}
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var poll <-chan time.Time
+	if strings.HasPrefix(filePath, "http://") || strings.HasPrefix(filePath, "https://") {
+		if interval > 0 {
+			tick := time.NewTicker(interval)
+			defer tick.Stop()
+			poll = tick.C
+		}
+	} else {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			log.Warn(fmt.Sprintf("not watching %s for changes: %v", filePath, err))
+		} else {
+			defer watcher.Close()
+			go watchFile(ctx, watcher, filePath, trigger)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			trigger()
+		case <-poll:
+			trigger()
+		case <-reload:
+			cfg, err := LoadConfig(filePath, token, s.config.StateFile)
+			if err == nil {
+				err = s.Reload(cfg)
+			}
+			if err != nil {
+				log.Error(fmt.Errorf("not reloading %s: %w", filePath, err))
+			}
+		}
+	}
+}
+
+// watchFile reports writes to the configuration file. The directory is watched rather than the file, editors and
+// kubernetes config maps replace the file instead of writing to it.
+func watchFile(ctx context.Context, watcher *fsnotify.Watcher, filePath string, trigger func()) {
+	if err := watcher.Add(filepath.Dir(filePath)); err != nil {
+		log.Warn(fmt.Sprintf("not watching %s for changes: %v", filePath, err))
+		return
+	}
+	name := filepath.Clean(filePath)
+	// an editor saving a file sends a handful of events, they are handled once things settle.
+	var settle <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if (filepath.Clean(event.Name) == name && !event.Has(fsnotify.Chmod)) || filepath.Base(event.Name) == "..data" {
+				settle = time.After(time.Second)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Warn(fmt.Sprintf("watching %s: %v", filePath, err))
+		case <-settle:
+			trigger()
+		}
+	}
+}
+
+// Reload applies the new configuration of its network to the running client. Sequencers are added or removed and their alerts
+// updated, the nodes are replaced and the thresholds, escalations and silences of the configuration updated, while
+// the stats, alarms and websocket session are kept. Settings only read on start are reported and left as they are.
+func (c *MetisianClient) Reload(cfg *Config) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	old := c.config
+	// both validated
+	oldNetwork, _ := old.network()
+	network, _ := cfg.network()
+	if network.ChainId != oldNetwork.ChainId {
+		return errors.New("chain_id can't change without a restart")
+	}
+
+	var changes []string
+	// the sequencers which stay keep their stats, only their alerts are updated.
+	sequencers := newSequencers(cfg)
+	updated := make(map[string]AlertConfig)
+	added := make([]*Sequencer, 0)
+	for name, seq := range sequencers {
+		current := c.Sequencers[name]
+		switch {
+		case current == nil || current.Address != seq.Address:
+			changes = append(changes, fmt.Sprintf("added sequencer %s (%s)", name, seq.Address))
+			seq.blocksResults = newBlocksResults()
+			seq.valInfo = &types.Validator{}
+			added = append(added, seq)
+		case !reflect.DeepEqual(current.Alerts, seq.Alerts):
+			if name == MetisianName {
+				changes = append(changes, "updated the default destinations")
+			} else {
+				changes = append(changes, "updated the alerts of "+name)
+			}
+			updated[name] = seq.Alerts
+			sequencers[name] = current
+		default:
+			sequencers[name] = current
+		}
+	}
+	removed := make([]string, 0)
+	for name, seq := range c.Sequencers {
+		if sequencers[name] == nil || sequencers[name].Address != seq.Address {
+			changes = append(changes, fmt.Sprintf("removed sequencer %s (%s)", name, seq.Address))
+			if sequencers[name] == nil {
+				removed = append(removed, name)
+			}
+		}
+	}
+
+	// nodes are matched by rpc url, so that their health and score survive.
+	nodes := make([]NodeInfo, len(cfg.NodeInfos))
+	copy(nodes, cfg.NodeInfos)
+	previous := make(map[string]NodeInfo)
+	for _, node := range c.Nodes {
+		previous[node.RpcURL] = node
+	}
+	active := c.rpcClient()
+	activeKept := active == nil
+	for i := range nodes {
+		if node, ok := previous[nodes[i].RpcURL]; ok {
+			nodes[i].health = node.health
+			if !reflect.DeepEqual(node, nodes[i]) {
+				changes = append(changes, "updated node "+nodes[i].RpcURL)
+			}
+			delete(previous, nodes[i].RpcURL)
+		} else {
+			nodes[i].health = &nodeHealth{}
+			changes = append(changes, "added node "+nodes[i].RpcURL)
+		}
+		if active != nil && active.rpcUrl == nodes[i].RpcURL {
+			activeKept = true
+		}
+	}
+	for rpcUrl := range previous {
+		changes = append(changes, "removed node "+rpcUrl)
+	}
+
+	settings := func(cfg *Config) []interface{} {
+		return []interface{}{cfg.RpcPool, cfg.NodeDownMin, cfg.NodeDownSeverity, cfg.NodeLagBlocks, cfg.Stalled,
+			cfg.StalledAlerts, cfg.MaxBackfill, cfg.AlertIfNoServers}
+	}
+	if !reflect.DeepEqual(settings(old), settings(cfg)) {
+		changes = append(changes, "updated the alert thresholds")
+	}
+	if !reflect.DeepEqual(old.Escalations, cfg.Escalations) {
+		changes = append(changes, "updated the escalation policies")
+	}
+	silencesChanged := !reflect.DeepEqual(old.Silences, cfg.Silences)
+	if silencesChanged {
+		changes = append(changes, "updated the silences")
+	}
+
+	logger := log.With(log.Fields{"chain_id": c.ChainId})
+	for _, setting := range restartOnly(old, cfg) {
+		logger.Warn(fmt.Sprintf("%s changed, it is only applied on restart", setting))
+	}
+	if len(changes) == 0 {
+		c.config = cfg
+		return nil
+	}
+
+	c.seqMux.Lock()
+	for name, alerts := range updated {
+		// the running sequencer may be in use without the lock, it is replaced by a copy with the new alerts.
+		seq := *c.Sequencers[name]
+		seq.Alerts = alerts
+		sequencers[name] = &seq
+	}
+	c.Sequencers = sequencers
+	c.Nodes = nodes
+	c.configure(cfg)
+	c.config = cfg
+	c.seqMux.Unlock()
+
+	for _, name := range removed {
+		// the alarms of a removed sequencer are no longer tracked, nor shown.
+		c.alarms.clearAll(name)
+		if c.EnableDash {
+			c.updateChan <- &dash.SequencerStatus{MsgType: "removed", ChainId: c.ChainId, Name: name}
+		}
+	}
+	if silencesChanged {
+		c.applyConfigSilences(cfg.Silences)
+	}
+	if len(added) > 0 && active != nil {
+		if err := c.GetSeqValInfos(); err != nil {
+			log.Warn(fmt.Sprintf("could not fetch the signing info of the new sequencers: %v", err))
+		}
+	}
+	for _, seq := range added {
+		if c.EnableDash {
+			c.updateChan <- &dash.SequencerStatus{
+				MsgType: "status",
+				ChainId: c.ChainId,
+				Name:    seq.name,
+				Address: seq.Address,
+				Blocks:  seq.blocksResults,
+			}
+		}
+	}
+	if !activeKept {
+		// closing the connection ends WsRun, the Run loop then reconnects to one of the new nodes.
+		logger.Warn("🔀 the websocket node was removed from the configuration, reconnecting")
+		_ = active.wsConn.Close()
+	}
+
+	for _, change := range changes {
+		logger.Info("🔄 " + change)
+	}
+	logger.Info("🔄 configuration reloaded")
+	return nil
+}
+
+// applyConfigSilences replaces the silences of the configuration file, the ones added at runtime are kept.
+func (c *MetisianClient) applyConfigSilences(silences []*Silence) {
+	c.silenceMux.Lock()
+	kept := make([]*Silence, 0, len(c.silences))
+	for _, s := range c.silences {
+		if !s.FromConfig {
+			kept = append(kept, s)
+		}
+	}
+	c.silences = kept
+	c.silenceMux.Unlock()
+
+	for i, s := range silences {
+		// the configuration is compared on the next reload, it is left as it was loaded.
+		s := *s
+		s.Id = fmt.Sprintf("config-%d", i)
+		s.FromConfig = true
+		if err := c.addSilence(&s); err != nil {
+			log.Warn(fmt.Sprintf("skipping silence %d from config: %v", i, err))
+		}
+	}
+}
+
+// restartOnly lists the settings of a network which changed, but are only read when metisian starts.
+func restartOnly(old, cfg *Config) []string {
+	changed := make([]string, 0)
+	check := func(name string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			changed = append(changed, name)
+		}
+	}
+	check("[history]", old.History, cfg.History)
+	oldNetwork, _ := old.network()
+	network, _ := cfg.network()
+	check("[network]", oldNetwork, network)
+	check("[notification_retry]", old.Retry, cfg.Retry)
+	// the telegram bot is started with its own copy of the settings.
+	if old.Telegram.Commands || cfg.Telegram.Commands {
+		check("the telegram bot", old.Telegram, cfg.Telegram)
+	}
+	return changed
+}
+
+// sharedRestartOnly lists the settings shared by every network which changed, but are only read when metisian
+// starts.
+func sharedRestartOnly(old, cfg *Config) []string {
+	changed := make([]string, 0)
+	check := func(name string, a, b interface{}) {
+		if !reflect.DeepEqual(a, b) {
+			changed = append(changed, name)
+		}
+	}
+	check("enable_dashboard", old.EnableDash, cfg.EnableDash)
+	check("listen_port", old.Listen, cfg.Listen)
+	check("hide_logs", old.HideLogs, cfg.HideLogs)
+	check("[dashboard]", old.Dashboard, cfg.Dashboard)
+	check("enable_prometheus", old.EnablePrometheus, cfg.EnablePrometheus)
+	check("prometheus_listen", old.PrometheusListen, cfg.PrometheusListen)
+	return changed
+}
+
+// newBlocksResults returns the sign status of the last blocks of a sequencer which hasn't been seen yet.
+func newBlocksResults() []int {
+	blocks := make([]int, showBlocks)
+	for i := range blocks {
+		blocks[i] = -1
+	}
+	return blocks
+}
@@ -9,18 +9,18 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// newRpc sets up the rpc client used for monitoring. It will try nodes in order until a working node is found.
+// newRpc sets up the rpc client used for monitoring. It will try nodes by score until a working node is found.
 // it will also get some initial info on the validator's status.
 func (c *MetisianClient) newRpc() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	var anyWorking bool // if healthchecks are running, we will skip to the first known good node.
-	for _, endpoint := range c.Nodes {
-		anyWorking = anyWorking || !endpoint.down
-	}
+	// if healthchecks are running, we will skip to the first known good node.
+	anyWorking := c.healthyNodes() > 0
 
 	// grab the first working endpoint
 	tryUrl := func(nodeInfo NodeInfo) (msg string, down, syncing bool) {
@@ -31,72 +31,64 @@ func (c *MetisianClient) newRpc() error {
 			down = true
 			return
 		}
-		c.client, err = NewMetisClient(nodeInfo, c)
+		status, err := getStatusWithEndpoint(ctx, nodeInfo.RpcURL)
 		if err != nil {
-			msg = fmt.Sprintf("❌ could not connect client: (%s) %s", nodeInfo.RpcURL, err)
-			log.Warn(msg)
+			msg = fmt.Sprintf("❌ could not get status: (%s) %s", nodeInfo.RpcURL, err)
 			down = true
+			log.Warn(msg)
 			return
 		}
-		var network string
-		var catching_up bool
-		status, err := c.client.RPCStatus()
-		if err != nil {
-			n, c, err := getStatusWithEndpoint(ctx, nodeInfo.RpcURL)
-			if err != nil {
-				msg = fmt.Sprintf("❌ could not get status: (%s) %s", nodeInfo.RpcURL, err)
-				down = true
-				log.Warn(msg)
-				return
-			}
-			network, catching_up = n, c
-		} else {
-			network, catching_up = status.NodeInfo.Network, status.SyncInfo.CatchingUp
-		}
-		if network != c.ChainId {
-			msg = fmt.Sprintf("networkId %s on %s does not match, expected %s, skipping", network, nodeInfo.RpcURL, c.ChainId)
+		if status.network != c.ChainId {
+			msg = fmt.Sprintf("networkId %s on %s does not match, expected %s, skipping", status.network, nodeInfo.RpcURL, c.ChainId)
 			down = true
 			log.Warn(msg)
 			return
 		}
-		if catching_up {
+		if status.catchingUp {
 			msg = fmt.Sprint("🐢 node is not synced, skipping ", nodeInfo.RpcURL)
 			syncing = true
 			down = true
 			log.Warn(msg)
 			return
 		}
+		mc, err := NewMetisClient(nodeInfo, c)
+		if err != nil {
+			msg = fmt.Sprintf("❌ could not connect client: (%s) %s", nodeInfo.RpcURL, err)
+			log.Warn(msg)
+			down = true
+			return
+		}
+		c.seqMux.Lock()
+		c.client = mc
+		c.seqMux.Unlock()
 		c.noNodes = false
 		return
 	}
-	down := func(endpoint NodeInfo, msg string) {
-		if !endpoint.down {
-			endpoint.down = true
-			endpoint.downSince = time.Now()
-		}
-		endpoint.lastMsg = msg
-	}
-	for _, endpoint := range c.Nodes {
-		if anyWorking && endpoint.down {
+	for _, endpoint := range c.rankedNodes() {
+		if anyWorking && endpoint.health.snapshot().down {
 			continue
 		}
 		if msg, failed, syncing := tryUrl(endpoint); failed {
-			endpoint.syncing = syncing
-			down(endpoint, msg)
+			if endpoint.health.markDown(msg, syncing) {
+				c.recordNodeEvent(endpoint.RpcURL, false, msg)
+			}
 			continue
 		}
 		return nil
 	}
 
 	c.noNodes = true
-	alarms.clearAll(MetisianName)
-	c.Sequencers[MetisianName].lastError = "no usable RPC endpoints available"
+	c.alarms.clearAll(MetisianName)
+	c.sequencers()[MetisianName].lastError = "no usable RPC endpoints available"
 
 	return errors.New("no usable endpoints available")
 }
 
+// monitorHealth probes every node once a minute, and refreshes the signing info of the sequencers.
 func (c *MetisianClient) monitorHealth(ctx context.Context) {
 	tick := time.NewTicker(time.Minute)
+	defer tick.Stop()
+	c.probeNodes(ctx)
 	if c.client == nil {
 		_ = c.newRpc()
 	}
@@ -108,48 +100,9 @@ func (c *MetisianClient) monitorHealth(ctx context.Context) {
 
 		case <-tick.C:
 			var err error
-			for _, node := range c.Nodes {
-				go func(node NodeInfo) {
-					alert := func(msg string) {
-						node.lastMsg = fmt.Sprintf("node %s is %s", node.RpcURL, msg)
-						if !node.AlertIfDown {
-							// even if we aren't alerting, we want to display the status in the dashboard.
-							node.down = true
-							return
-						}
-						if !node.down {
-							node.down = true
-							node.downSince = time.Now()
-						}
-						log.Warn("⚠️ " + node.lastMsg)
-					}
-					status, e := c.client.RPCStatus()
-					if e != nil {
-						alert("down")
-						return
-					}
-					if status.NodeInfo.Network != c.ChainId {
-						alert("on the wrong network")
-						return
-					}
-					if status.SyncInfo.CatchingUp {
-						alert("not synced")
-						node.syncing = true
-						return
-					}
-
-					// node's OK, clear the note
-					if node.down {
-						node.lastMsg = ""
-						node.wasDown = true
-					}
-					node.down = false
-					node.syncing = false
-					node.downSince = time.Unix(0, 0)
-					c.noNodes = false
-					log.Info(fmt.Sprintf("🟢 node %s is healthy", node.RpcURL))
-				}(node)
-			}
+			c.probeNodes(ctx)
+			c.checkConsensus(ctx)
+			c.checkFailover()
 
 			if c.client == nil {
 				e := c.newRpc()
@@ -171,11 +124,50 @@ func (c *MetisianClient) monitorHealth(ctx context.Context) {
 	}
 }
 
-func getStatusWithEndpoint(ctx context.Context, u string) (string, bool, error) {
+// tendermintStatus is the part of the /status response used to check a node.
+type tendermintStatus struct {
+	network    string
+	catchingUp bool
+	height     int64
+}
+
+func getStatusWithEndpoint(ctx context.Context, u string) (*tendermintStatus, error) {
+	b, err := rpcGet(ctx, u, "/status")
+	if err != nil {
+		return nil, err
+	}
+
+	var status struct {
+		Result struct {
+			NodeInfo struct {
+				Network string `json:"network"`
+			} `json:"node_info"`
+			SyncInfo struct {
+				LatestBlockHeight string `json:"latest_block_height"`
+				CatchingUp        bool   `json:"catching_up"`
+			} `json:"sync_info"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(b, &status); err != nil {
+		return nil, err
+	}
+	if status.Result.NodeInfo.Network == "" {
+		return nil, errors.New("unexpected status response")
+	}
+	height, _ := strconv.ParseInt(status.Result.SyncInfo.LatestBlockHeight, 10, 64)
+	return &tendermintStatus{
+		network:    status.Result.NodeInfo.Network,
+		catchingUp: status.Result.SyncInfo.CatchingUp,
+		height:     height,
+	}, nil
+}
+
+// rpcGet queries a path of a tendermint rpc endpoint over http.
+func rpcGet(ctx context.Context, u, path string) ([]byte, error) {
 	// Parse the URL
 	parsedURL, err := url.Parse(u)
 	if err != nil {
-		return "", false, err
+		return nil, err
 	}
 
 	// Check if the scheme is 'tcp' and modify to 'http'
@@ -183,38 +175,24 @@ func getStatusWithEndpoint(ctx context.Context, u string) (string, bool, error)
 		parsedURL.Scheme = "http"
 	}
 
-	queryPath := fmt.Sprintf("%s/status", parsedURL.String())
+	queryPath := strings.TrimRight(parsedURL.String(), "/") + path
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, queryPath, nil)
 	if err != nil {
-		return "", false, err
+		return nil, err
 	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return "", false, err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	b, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", false, err
-	}
-
-	type tendermintStatus struct {
-		JsonRPC string `json:"jsonrpc"`
-		ID      int    `json:"id"`
-		Result  struct {
-			NodeInfo struct {
-				Network string `json:"network"`
-			} `json:"node_info"`
-			SyncInfo struct {
-				CatchingUp bool `json:"catching_up"`
-			} `json:"sync_info"`
-		} `json:"result"`
+		return nil, err
 	}
-	var status tendermintStatus
-	if err := json.Unmarshal(b, &status); err != nil {
-		return "", false, err
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected http status %d", resp.StatusCode)
 	}
-	return status.Result.NodeInfo.Network, status.Result.SyncInfo.CatchingUp, nil
+	return b, nil
 }
@@ -0,0 +1,200 @@
+package metis
+
+import (
+	"errors"
+	"fmt"
+	"github.com/pelletier/go-toml/v2"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// envRex matches the ${NAME} references to environment variables. A bare $ is left alone, bcrypt hashes are full of
+// them.
+var envRex = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+const redacted = "-redacted-"
+
+// resolveSecrets resolves the secrets of every string of the configuration and of its networks, see resolveSecret.
+func (cfg *Config) resolveSecrets() error {
+	errs := walkStrings(reflect.ValueOf(cfg), "", false, resolveSecret)
+	// the networks inherit the top level settings, their problems are only reported once.
+	seen := make(map[string]bool)
+	for _, err := range errs {
+		seen[err.Error()] = true
+	}
+	for i, n := range cfg.networks {
+		for _, err := range walkStrings(reflect.ValueOf(n), "", false, resolveSecret) {
+			if !seen[err.Error()] {
+				errs = append(errs, fmt.Errorf("%s: %w", networkName(i, n), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resolveSecret replaces the ${NAME} references by the environment variable, and then a value starting with file://
+// by the content of the file, without its trailing newline (ex. file:///run/secrets/telegram_api_key).
+func resolveSecret(path, s string, _ bool) (string, error) {
+	var missing []string
+	s = envRex.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envRex.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return s, fmt.Errorf("%s: environment variable %s is not set", path, strings.Join(missing, ", "))
+	}
+
+	if strings.HasPrefix(s, "file://") {
+		//#nosec -- the file is named by the configuration
+		b, err := os.ReadFile(strings.TrimPrefix(s, "file://"))
+		if err != nil {
+			return s, fmt.Errorf("%s: %w", path, err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	return s, nil
+}
+
+// walkStrings calls fn with every string reachable from v through exported fields, pointers, slices and string maps,
+// and sets the string to the value returned. path is the toml key of the string (ex. telegram.api_key) and secret
+// whether its field is tagged `secret:"true"`.
+func walkStrings(v reflect.Value, path string, secret bool, fn func(path, s string, secret bool) (string, error)) []error {
+	var errs []error
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			errs = walkStrings(v.Elem(), path, secret, fn)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("toml"), ",")[0]
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			fieldPath := path
+			if !f.Anonymous {
+				if name == "" {
+					name = f.Name
+				}
+				fieldPath = strings.TrimPrefix(path+"."+name, ".")
+			}
+			errs = append(errs, walkStrings(v.Field(i), fieldPath, f.Tag.Get("secret") == "true", fn)...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			errs = append(errs, walkStrings(v.Index(i), fmt.Sprintf("%s[%d]", path, i), secret, fn)...)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			// the [[networks]] tables are resolved once loaded.
+			return nil
+		}
+		for _, k := range v.MapKeys() {
+			s, err := fn(path+"."+k.String(), v.MapIndex(k).String(), secret)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			v.SetMapIndex(k, reflect.ValueOf(s).Convert(v.Type().Elem()))
+		}
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		s, err := fn(path, v.String(), secret)
+		if err != nil {
+			return []error{err}
+		}
+		v.SetString(s)
+	}
+	return errs
+}
+
+// RedactedToml encodes the settings of the configuration which aren't left to their default, with the secrets
+// redacted. The [[networks]] are encoded with the settings they inherit.
+func (cfg *Config) RedactedToml() ([]byte, error) {
+	top, err := redactedTable(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Networks) > 0 {
+		networks := make([]interface{}, 0, len(cfg.networks))
+		for _, n := range cfg.networks {
+			table, err := redactedTable(n)
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range sharedKeys {
+				delete(table, key)
+			}
+			networks = append(networks, table)
+		}
+		top["networks"] = networks
+	}
+	return toml.Marshal(top)
+}
+
+// redactedTable returns the settings of a configuration without its [[networks]], the secrets of a copy are
+// redacted and its zero values dropped.
+func redactedTable(cfg *Config) (map[string]interface{}, error) {
+	b, err := toml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	if err = toml.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	c.Networks = nil
+	walkStrings(reflect.ValueOf(c), "", false, func(_, s string, secret bool) (string, error) {
+		if secret && s != "" {
+			return redacted, nil
+		}
+		return s, nil
+	})
+
+	if b, err = toml.Marshal(c); err != nil {
+		return nil, err
+	}
+	table := make(map[string]interface{})
+	if err = toml.Unmarshal(b, &table); err != nil {
+		return nil, err
+	}
+	prune(table)
+	return table, nil
+}
+
+// prune drops the zero values of a table, and the tables and arrays left empty. The tables of an array are kept,
+// their position may matter.
+func prune(table map[string]interface{}) {
+	for k, v := range table {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			prune(v)
+			if len(v) == 0 {
+				delete(table, k)
+			}
+		case []interface{}:
+			for _, e := range v {
+				if t, ok := e.(map[string]interface{}); ok {
+					prune(t)
+				}
+			}
+			if len(v) == 0 {
+				delete(table, k)
+			}
+		default:
+			if v == nil || reflect.ValueOf(v).IsZero() {
+				delete(table, k)
+			}
+		}
+	}
+}
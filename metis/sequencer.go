@@ -2,6 +2,7 @@ package metis
 
 import (
 	"errors"
+	"github.com/b-harvest/metisian/log"
 	"github.com/metis-seq/themis/types"
 )
 
@@ -39,12 +40,18 @@ func NewSequencer(i SequencerInfo) Sequencer {
 	}
 }
 
+// logger adds the sequencer name and address to log events.
+func (s *Sequencer) logger() *log.Logger {
+	return log.With(log.Fields{"sequencer": s.name, "address": s.Address})
+}
+
 func (c *MetisianClient) GetSeqValInfos() (err error) {
-	if c.client == nil {
+	client := c.rpcClient()
+	if client == nil {
 		return errors.New("nil rpc client")
 	}
 	var vset = new(types.ValidatorSet)
-	vset, err = c.client.GetValidatorSet()
+	vset, err = client.GetValidatorSet()
 	if err != nil {
 		return err
 	}
@@ -100,7 +100,7 @@ query ($skip: Int, $first: Int, $address: String) {
 					req.Header.Set("Cache-Control", "no-cache")
 
 					var respData SeqData
-					if err = c.client.seqSetClient.Run(ctx, req, &respData); err != nil {
+					if err = c.rpcClient().runSequencerSet(ctx, req, &respData); err != nil {
 						log.Warn(fmt.Sprintf("%v", err))
 						return
 					}
@@ -121,6 +121,7 @@ query ($skip: Int, $first: Int, $address: String) {
 					}
 
 					s.statNewSeqData = &respData
+					c.recordEpochs(s, respData.Epoches)
 				}(seq)
 			}
 
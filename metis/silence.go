@@ -0,0 +1,245 @@
+package metis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	"time"
+)
+
+const (
+	// maxSilenceWindow bounds the length of a recurring maintenance window.
+	maxSilenceWindow = 7 * 24 * time.Hour
+	// silenceInterval is how often the alarms of the silences which ended are sent.
+	silenceInterval = 30 * time.Second
+)
+
+// Silence mutes alerts. It matches a sequencer, a single alert id (ex. <address>consecutive, <address>respan or the
+// rpc url of a node), both, or every alert when neither is set. Alarms are still tracked while silenced, they are just
+// not sent until the silence ends.
+//
+// A silence is either a one-off silence lasting until Until, or a recurring maintenance window: Schedule is a cron
+// expression (UTC) opening a window of Duration, Until then optionally ends the schedule.
+type Silence struct {
+	Id        string    `json:"id" toml:"-"`
+	Sequencer string    `json:"sequencer,omitempty" toml:"sequencer"`
+	AlertId   string    `json:"alert_id,omitempty" toml:"alert_id"`
+	Until     time.Time `json:"until" toml:"until"`
+	Schedule  string    `json:"schedule,omitempty" toml:"schedule"`
+	Duration  string    `json:"duration,omitempty" toml:"duration"`
+	Comment   string    `json:"comment,omitempty" toml:"comment"`
+	CreatedBy string    `json:"created_by,omitempty" toml:"created_by"`
+	// FromConfig silences are loaded from the config file on every start, and are neither saved nor deletable.
+	FromConfig bool `json:"from_config" toml:"-"`
+
+	cron   *cronSchedule
+	window time.Duration
+}
+
+// init validates the silence and parses its schedule. A one-off silence given only a duration starts now.
+func (s *Silence) init(now time.Time) error {
+	var err error
+	if s.Duration != "" {
+		s.window, err = time.ParseDuration(s.Duration)
+		if err != nil || s.window <= 0 {
+			return fmt.Errorf("invalid silence duration %q", s.Duration)
+		}
+	}
+
+	if s.Schedule == "" {
+		if s.Until.IsZero() {
+			if s.window == 0 {
+				return errors.New("a silence needs either until, duration or schedule")
+			}
+			s.Until = now.Add(s.window)
+		}
+		return nil
+	}
+
+	s.cron, err = parseCron(s.Schedule)
+	if err != nil {
+		return err
+	}
+	if s.window == 0 || s.window > maxSilenceWindow {
+		return fmt.Errorf("a maintenance window needs a duration of at most %s", maxSilenceWindow)
+	}
+	return nil
+}
+
+func (s *Silence) expired(now time.Time) bool {
+	return !s.Until.IsZero() && !now.Before(s.Until)
+}
+
+func (s *Silence) active(now time.Time) bool {
+	if s.expired(now) {
+		return false
+	}
+	if s.cron != nil {
+		return s.cron.within(now, s.window)
+	}
+	return true
+}
+
+func (s *Silence) matches(seqName, alertId string) bool {
+	return (s.Sequencer == "" || s.Sequencer == seqName) && (s.AlertId == "" || s.AlertId == alertId)
+}
+
+func (s *Silence) String() string {
+	target := "every alert"
+	switch {
+	case s.Sequencer != "" && s.AlertId != "":
+		target = fmt.Sprintf("%s (%s)", s.Sequencer, s.AlertId)
+	case s.Sequencer != "":
+		target = s.Sequencer
+	case s.AlertId != "":
+		target = s.AlertId
+	}
+	if s.cron != nil {
+		return fmt.Sprintf("%s during %q for %s", target, s.Schedule, s.Duration)
+	}
+	return fmt.Sprintf("%s until %s", target, s.Until.UTC().Format(time.RFC3339))
+}
+
+// addSilence validates and registers a silence, expired silences are dropped at the same time.
+func (c *MetisianClient) addSilence(s *Silence) error {
+	now := time.Now()
+	if err := s.init(now); err != nil {
+		return err
+	}
+	if s.Sequencer != "" && c.sequencers()[s.Sequencer] == nil {
+		return fmt.Errorf("unknown sequencer %q", s.Sequencer)
+	}
+	if s.expired(now) {
+		return errors.New("silence has already expired")
+	}
+	if s.Id == "" {
+		s.Id = newSilenceId()
+	}
+
+	c.silenceMux.Lock()
+	defer c.silenceMux.Unlock()
+	active := make([]*Silence, 0, len(c.silences)+1)
+	for _, existing := range c.silences {
+		if !existing.expired(now) {
+			active = append(active, existing)
+		}
+	}
+	c.silences = append(active, s)
+	if !s.FromConfig {
+		log.Info(fmt.Sprintf("🔕 %s silenced %s", s.CreatedBy, s))
+	}
+	return nil
+}
+
+// deleteSilence removes a silence by id, silences from the config file can't be removed.
+func (c *MetisianClient) deleteSilence(id string) error {
+	c.silenceMux.Lock()
+	defer c.silenceMux.Unlock()
+	for i, s := range c.silences {
+		if s.Id != id {
+			continue
+		}
+		if s.FromConfig {
+			return fmt.Errorf("silence %s is defined in the config file", id)
+		}
+		c.silences = append(c.silences[:i], c.silences[i+1:]...)
+		log.Info(fmt.Sprintf("🔔 removed silence of %s", s))
+		return nil
+	}
+	return fmt.Errorf("silence %s not found", id)
+}
+
+// getSilences returns the silences which haven't expired yet.
+func (c *MetisianClient) getSilences() []*Silence {
+	now := time.Now()
+	c.silenceMux.RLock()
+	defer c.silenceMux.RUnlock()
+	result := make([]*Silence, 0, len(c.silences))
+	for _, s := range c.silences {
+		if !s.expired(now) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func (c *MetisianClient) isSilenced(seqName, alertId string) bool {
+	now := time.Now()
+	c.silenceMux.RLock()
+	defer c.silenceMux.RUnlock()
+	for _, s := range c.silences {
+		if s.matches(seqName, alertId) && s.active(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// holdSilenced keeps a silenced alarm until its silence ends, any other alert for it (ex. its resolution) drops it.
+// It returns true when the dropped alarm was never delivered anywhere, there is then nothing to resolve.
+func (a *alarmCache) holdSilenced(key string, msg *alertMsg, silenced bool) (undelivered bool) {
+	a.notifyMux.Lock()
+	defer a.notifyMux.Unlock()
+	if silenced {
+		a.silenced[key] = msg
+		return false
+	}
+	if a.silenced[key] == nil {
+		return false
+	}
+	delete(a.silenced, key)
+	for _, sent := range a.Sent {
+		if !sent[key].IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// releaseSilenced raises again the silenced alarms which are still active once their silence ended, they would
+// otherwise never be sent since the alarm is already tracked.
+func (c *MetisianClient) releaseSilenced() {
+	c.alarms.notifyMux.Lock()
+	held := make([]*alertMsg, 0, len(c.alarms.silenced))
+	for key, msg := range c.alarms.silenced {
+		if c.alarms.AllAlarms[msg.sequencer][msg.message].IsZero() {
+			// resolved, or cleared without a resolution
+			delete(c.alarms.silenced, key)
+			continue
+		}
+		held = append(held, msg)
+	}
+	c.alarms.notifyMux.Unlock()
+
+	for _, msg := range held {
+		if c.isSilenced(msg.sequencer, msg.uniqueId) {
+			continue
+		}
+		msg.logger().Info("🔔 silence ended, alarm is still active: " + msg.message)
+		id := msg.uniqueId
+		c.alert(msg.sequencer, msg.message, msg.severity, false, false, &id)
+	}
+}
+
+// watchSilences periodically sends the alarms of the silences which ended, see releaseSilenced.
+func (c *MetisianClient) watchSilences(ctx context.Context) {
+	ticker := time.NewTicker(silenceInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.releaseSilenced()
+		}
+	}
+}
+
+func newSilenceId() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,68 @@
+package metis
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// silenceHandler serves the silences api on the dashboard:
+//
+//	GET    /silences       lists the active silences
+//	POST   /silences       creates a silence, the body is a json encoded Silence
+//	DELETE /silences/{id}  removes a silence
+func (c *MetisianClient) silenceHandler() http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+
+		id := strings.Trim(strings.TrimPrefix(request.URL.Path, "/silences"), "/")
+		switch {
+		case request.Method == http.MethodGet && id == "":
+			writeJson(writer, http.StatusOK, c.getSilences())
+
+		case request.Method == http.MethodPost && id == "":
+			s := &Silence{}
+			if err := json.NewDecoder(request.Body).Decode(s); err != nil {
+				writeJsonError(writer, http.StatusBadRequest, err.Error())
+				return
+			}
+			// ids are always generated, and only the config file creates config silences.
+			s.Id = ""
+			s.FromConfig = false
+			if s.CreatedBy == "" {
+				s.CreatedBy = "api:" + request.RemoteAddr
+			}
+			if err := c.addSilence(s); err != nil {
+				writeJsonError(writer, http.StatusBadRequest, err.Error())
+				return
+			}
+			writeJson(writer, http.StatusCreated, s)
+
+		case request.Method == http.MethodDelete && id != "":
+			if err := c.deleteSilence(id); err != nil {
+				writeJsonError(writer, http.StatusNotFound, err.Error())
+				return
+			}
+			writer.WriteHeader(http.StatusNoContent)
+
+		default:
+			writeJsonError(writer, http.StatusMethodNotAllowed, request.Method+" is not supported on "+request.URL.Path)
+		}
+	})
+}
+
+func writeJson(writer http.ResponseWriter, status int, v interface{}) {
+	j, err := json.Marshal(v)
+	if err != nil {
+		writeJsonError(writer, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writer.WriteHeader(status)
+	_, _ = writer.Write(j)
+}
+
+func writeJsonError(writer http.ResponseWriter, status int, msg string) {
+	j, _ := json.Marshal(map[string]string{"error": msg})
+	writer.WriteHeader(status)
+	_, _ = writer.Write(j)
+}
@@ -0,0 +1,42 @@
+package metis
+
+import (
+	"testing"
+)
+
+func TestHoldSilenced(t *testing.T) {
+	const key = "seqmissed 10 blocks"
+	tests := []struct {
+		name string
+		// held was silenced before, deliveredTo received the alarm before its silence.
+		held        bool
+		deliveredTo string
+		silenced    bool
+		want        bool
+		wantHeld    bool
+	}{
+		{name: "silenced", silenced: true, wantHeld: true},
+		{name: "silenced again", held: true, silenced: true, wantHeld: true},
+		{name: "never held", want: false},
+		{name: "held and never delivered", held: true, want: true},
+		{name: "held after it was delivered", held: true, deliveredTo: "slack", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAlarmCache()
+			msg := &alertMsg{sequencer: "seq", message: "missed 10 blocks"}
+			if tt.deliveredTo != "" {
+				a.markDelivered(tt.deliveredTo, key, false)
+			}
+			if tt.held {
+				a.holdSilenced(key, msg, true)
+			}
+			if got := a.holdSilenced(key, msg, tt.silenced); got != tt.want {
+				t.Errorf("holdSilenced = %v, want %v", got, tt.want)
+			}
+			if held := a.silenced[key] != nil; held != tt.wantHeld {
+				t.Errorf("held = %v, want %v", held, tt.wantHeld)
+			}
+		})
+	}
+}
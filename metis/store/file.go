@@ -0,0 +1,300 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+const (
+	signsFile  = "signs.jsonl"
+	epochsFile = "epochs.jsonl"
+	alertsFile = "alerts.jsonl"
+	nodesFile  = "nodes.jsonl"
+
+	// DefaultMaxFileMB is the size of a file of the file backend after which it is rotated.
+	DefaultMaxFileMB = 64
+)
+
+// fileStore keeps the history as json lines in a directory, one file per kind of record. It needs no database, but
+// queries read the whole file so it suits small setups. An updated epoch is appended again, the last line wins.
+//
+// A file growing over maxSize is renamed with a .1 suffix, replacing the previous one, so the history only goes back
+// two files. Queries read both without holding the lock of the writes.
+type fileStore struct {
+	dir     string
+	maxSize int64
+	mux     sync.Mutex
+}
+
+// NewFile opens the history kept in dir, creating it if needed. Files are rotated once larger than maxFileMB,
+// DefaultMaxFileMB when 0.
+func NewFile(dir string, maxFileMB int) (Store, error) {
+	if dir == "" {
+		return nil, errors.New("the file history backend needs a path")
+	}
+	if maxFileMB < 0 {
+		return nil, errors.New("the file history backend max_file_mb can't be negative")
+	}
+	if maxFileMB == 0 {
+		maxFileMB = DefaultMaxFileMB
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &fileStore{dir: dir, maxSize: int64(maxFileMB) << 20}, nil
+}
+
+func (s *fileStore) append(name string, rec interface{}) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	f, err := os.OpenFile(filepath.Join(s.dir, name), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	var info os.FileInfo
+	if err == nil {
+		info, err = f.Stat()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && info.Size() > s.maxSize {
+		path := filepath.Join(s.dir, name)
+		err = os.Rename(path, path+".1")
+	}
+	return err
+}
+
+// snapshot is a file of the history as it was when a query started, lines appended since are left out.
+type snapshot struct {
+	name string
+	file *os.File
+	size int64
+}
+
+// open opens the rotated file and then the current one. Appends write whole lines under the lock, so the lines up to
+// the size seen here are complete and the files can then be read without the lock.
+func (s *fileStore) open(name string) ([]snapshot, error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	snapshots := make([]snapshot, 0, 2)
+	for _, n := range []string{name + ".1", name} {
+		f, err := os.Open(filepath.Join(s.dir, n))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		var info os.FileInfo
+		if err == nil {
+			info, err = f.Stat()
+		}
+		if err != nil {
+			if f != nil {
+				_ = f.Close()
+			}
+			for _, snap := range snapshots {
+				_ = snap.file.Close()
+			}
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot{name: n, file: f, size: info.Size()})
+	}
+	return snapshots, nil
+}
+
+// scan decodes every line of a file with decode, in the order they were written.
+func (s *fileStore) scan(ctx context.Context, name string, decode func(line []byte) error) error {
+	snapshots, err := s.open(name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, snap := range snapshots {
+			_ = snap.file.Close()
+		}
+	}()
+
+	for _, snap := range snapshots {
+		scanner := bufio.NewScanner(io.LimitReader(snap.file, snap.size))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			if line%10000 == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			if err = decode(scanner.Bytes()); err != nil {
+				return fmt.Errorf("%s line %d: %w", snap.name, line, err)
+			}
+		}
+		if err = scanner.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *fileStore) SaveSign(_ context.Context, rec *SignRecord) error {
+	return s.append(signsFile, rec)
+}
+
+func (s *fileStore) SaveEpoch(_ context.Context, rec *EpochRecord) error {
+	return s.append(epochsFile, rec)
+}
+
+func (s *fileStore) SaveAlert(_ context.Context, rec *AlertRecord) error {
+	return s.append(alertsFile, rec)
+}
+
+func (s *fileStore) SaveNodeEvent(_ context.Context, rec *NodeEvent) error {
+	return s.append(nodesFile, rec)
+}
+
+func (s *fileStore) Signs(ctx context.Context, f Filter) ([]*SignRecord, error) {
+	// a backfilled height may be recorded twice, the last line wins.
+	type key struct {
+		chainId, sequencer string
+		height             int64
+	}
+	latest := make(map[key]int)
+	recs := make([]*SignRecord, 0)
+	err := s.scan(ctx, signsFile, func(line []byte) error {
+		rec := &SignRecord{}
+		if err := json.Unmarshal(line, rec); err != nil {
+			return err
+		}
+		if !f.matchString(f.ChainId, rec.ChainId) || !f.matchString(f.Sequencer, rec.Sequencer) ||
+			!f.matchHeight(rec.Height) || !f.matchTime(rec.Time) {
+			return nil
+		}
+		k := key{rec.ChainId, rec.Sequencer, rec.Height}
+		if i, ok := latest[k]; ok {
+			recs[i] = rec
+			return nil
+		}
+		latest[k] = len(recs)
+		recs = append(recs, rec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortSigns(recs)
+	return paginate(recs, f), nil
+}
+
+func (s *fileStore) Epochs(ctx context.Context, f Filter) ([]*EpochRecord, error) {
+	type key struct {
+		chainId, address string
+		epochId          int64
+	}
+	latest := make(map[key]int)
+	recs := make([]*EpochRecord, 0)
+	err := s.scan(ctx, epochsFile, func(line []byte) error {
+		rec := &EpochRecord{}
+		if err := json.Unmarshal(line, rec); err != nil {
+			return err
+		}
+		if !f.matchString(f.ChainId, rec.ChainId) || !f.matchString(f.Sequencer, rec.Sequencer) || !f.matchTime(rec.Time) ||
+			(f.FromHeight > 0 && rec.EndBlock < f.FromHeight) || (f.ToHeight > 0 && rec.StartBlock > f.ToHeight) {
+			return nil
+		}
+		k := key{rec.ChainId, rec.Address, rec.EpochId}
+		if i, ok := latest[k]; ok {
+			// keep when the epoch was first seen
+			rec.Time = recs[i].Time
+			recs[i] = rec
+			return nil
+		}
+		latest[k] = len(recs)
+		recs = append(recs, rec)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortEpochs(recs)
+	return paginate(recs, f), nil
+}
+
+func (s *fileStore) Alerts(ctx context.Context, f Filter) ([]*AlertRecord, error) {
+	recs := make([]*AlertRecord, 0)
+	err := s.scan(ctx, alertsFile, func(line []byte) error {
+		rec := &AlertRecord{}
+		if err := json.Unmarshal(line, rec); err != nil {
+			return err
+		}
+		if f.matchString(f.ChainId, rec.ChainId) && f.matchString(f.Sequencer, rec.Sequencer) && f.matchTime(rec.Time) {
+			recs = append(recs, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	reverse(recs)
+	return paginate(recs, f), nil
+}
+
+func (s *fileStore) NodeEvents(ctx context.Context, f Filter) ([]*NodeEvent, error) {
+	recs := make([]*NodeEvent, 0)
+	err := s.scan(ctx, nodesFile, func(line []byte) error {
+		rec := &NodeEvent{}
+		if err := json.Unmarshal(line, rec); err != nil {
+			return err
+		}
+		if f.matchString(f.ChainId, rec.ChainId) && f.matchString(f.Node, rec.RpcURL) && f.matchTime(rec.Time) {
+			recs = append(recs, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	reverse(recs)
+	return paginate(recs, f), nil
+}
+
+func (s *fileStore) Close() error {
+	return nil
+}
+
+// sortSigns orders sign records by height, newest first.
+func sortSigns(recs []*SignRecord) {
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Height > recs[j].Height })
+}
+
+// sortEpochs orders epochs by id, newest first.
+func sortEpochs(recs []*EpochRecord) {
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].EpochId > recs[j].EpochId })
+}
+
+func reverse[T any](recs []T) {
+	for i, j := 0, len(recs)-1; i < j; i, j = i+1, j-1 {
+		recs[i], recs[j] = recs[j], recs[i]
+	}
+}
+
+func paginate[T any](recs []T, f Filter) []T {
+	if f.Offset >= len(recs) {
+		return recs[:0]
+	}
+	recs = recs[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(recs) {
+		recs = recs[:f.Limit]
+	}
+	return recs
+}
@@ -0,0 +1,201 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func signRecord(height int64, status string) *SignRecord {
+	return &SignRecord{ChainId: "andromeda", Sequencer: "seq", Address: "0xabc", Height: height, Status: status,
+		Time: time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)}
+}
+
+func lineCount(t *testing.T, path string) int {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return -1
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, c := range b {
+		if c == '\n' {
+			n++
+		}
+	}
+	return n
+}
+
+func TestNewFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		dir       string
+		maxFileMB int
+		wantErr   bool
+		wantMax   int64
+	}{
+		{"default size", t.TempDir(), 0, false, DefaultMaxFileMB << 20},
+		{"given size", t.TempDir(), 2, false, 2 << 20},
+		{"no path", "", 0, true, 0},
+		{"negative size", t.TempDir(), -1, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewFile(tt.dir, tt.maxFileMB)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewFile error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s.(*fileStore).maxSize != tt.wantMax {
+				t.Errorf("maxSize = %d, want %d", s.(*fileStore).maxSize, tt.wantMax)
+			}
+		})
+	}
+}
+
+func TestFileStoreRotation(t *testing.T) {
+	// every line has the same length, the files rotate once they hold three lines.
+	line, err := json.Marshal(signRecord(100, "signed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxSize := int64(2 * (len(line) + 1))
+
+	tests := []struct {
+		name         string
+		writes       int
+		currentLines int // -1 when the file doesn't exist
+		rotatedLines int
+		heights      []int64 // newest first
+	}{
+		{"under the limit", 2, 2, -1, []int64{101, 100}},
+		{"rotated", 3, -1, 3, []int64{102, 101, 100}},
+		{"both files", 5, 2, 3, []int64{104, 103, 102, 101, 100}},
+		{"rotated twice", 7, 1, 3, []int64{106, 105, 104, 103}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &fileStore{dir: t.TempDir(), maxSize: maxSize}
+			for i := 0; i < tt.writes; i++ {
+				if err := s.SaveSign(ctx, signRecord(100+int64(i), "signed")); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if n := lineCount(t, filepath.Join(s.dir, signsFile)); n != tt.currentLines {
+				t.Errorf("%s has %d lines, want %d", signsFile, n, tt.currentLines)
+			}
+			if n := lineCount(t, filepath.Join(s.dir, signsFile+".1")); n != tt.rotatedLines {
+				t.Errorf("%s.1 has %d lines, want %d", signsFile, n, tt.rotatedLines)
+			}
+
+			recs, err := s.Signs(ctx, Filter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) != len(tt.heights) {
+				t.Fatalf("got %d signs, want %d", len(recs), len(tt.heights))
+			}
+			for i, rec := range recs {
+				if rec.Height != tt.heights[i] {
+					t.Errorf("sign %d has height %d, want %d", i, rec.Height, tt.heights[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFileStoreLastLineWins(t *testing.T) {
+	first := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	later := first.Add(time.Hour)
+	// a file is rotated by its second sign, a single proposed sign fits.
+	line, err := json.Marshal(signRecord(100, "proposed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneSign := int64(len(line) + 1)
+	tests := []struct {
+		name    string
+		maxSize int64
+		signs   []*SignRecord
+		epochs  []*EpochRecord
+		status  map[int64]string
+		endOf   map[int64]int64
+	}{
+		{
+			name:    "backfilled height",
+			maxSize: 1 << 20,
+			signs:   []*SignRecord{signRecord(100, "missed"), signRecord(101, "signed"), signRecord(100, "signed")},
+			status:  map[int64]string{100: "signed", 101: "signed"},
+		},
+		{
+			name:    "across a rotation",
+			maxSize: oneSign,
+			signs:   []*SignRecord{signRecord(100, "missed"), signRecord(101, "signed"), signRecord(100, "proposed")},
+			status:  map[int64]string{100: "proposed", 101: "signed"},
+		},
+		{
+			name:    "updated epoch",
+			maxSize: 1 << 20,
+			epochs: []*EpochRecord{
+				{ChainId: "andromeda", Sequencer: "seq", Address: "0xabc", EpochId: 7, StartBlock: 100, EndBlock: 0, Time: first},
+				{ChainId: "andromeda", Sequencer: "seq", Address: "0xdef", EpochId: 7, StartBlock: 100, EndBlock: 150, Time: first},
+				{ChainId: "andromeda", Sequencer: "seq", Address: "0xabc", EpochId: 7, StartBlock: 100, EndBlock: 200, Recommited: true, Time: later},
+			},
+			endOf: map[int64]int64{7: 200},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := &fileStore{dir: t.TempDir(), maxSize: tt.maxSize}
+			for _, rec := range tt.signs {
+				if err := s.SaveSign(ctx, rec); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, rec := range tt.epochs {
+				if err := s.SaveEpoch(ctx, rec); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			signs, err := s.Signs(ctx, Filter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(signs) != len(tt.status) {
+				t.Fatalf("got %d signs, want %d", len(signs), len(tt.status))
+			}
+			for _, rec := range signs {
+				if rec.Status != tt.status[rec.Height] {
+					t.Errorf("height %d is %s, want %s", rec.Height, rec.Status, tt.status[rec.Height])
+				}
+			}
+
+			epochs, err := s.Epochs(ctx, Filter{Sequencer: "seq"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, rec := range epochs {
+				if rec.Address != "0xabc" {
+					// another sequencer's epoch with the same id is kept apart
+					continue
+				}
+				if rec.EndBlock != tt.endOf[rec.EpochId] || !rec.Recommited {
+					t.Errorf("epoch %d ends at %d recommited %v, want the last line", rec.EpochId, rec.EndBlock, rec.Recommited)
+				}
+				if !rec.Time.Equal(first) {
+					t.Errorf("epoch %d has time %s, want when it was first seen %s", rec.EpochId, rec.Time, first)
+				}
+			}
+			if len(epochs) != 2*len(tt.endOf) {
+				t.Errorf("got %d epochs, want %d", len(epochs), 2*len(tt.endOf))
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+// sqlStore keeps the history in a database through gorm, the tables are migrated when it is opened.
+type sqlStore struct {
+	db *gorm.DB
+}
+
+// NewMySQL connects to a mysql database, the dsn must set parseTime=true.
+func NewMySQL(dsn string) (Store, error) {
+	if dsn == "" {
+		return nil, errors.New("the mysql history backend needs a dsn")
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, err
+	}
+	if err = db.AutoMigrate(&SignRecord{}, &EpochRecord{}, &AlertRecord{}, &NodeEvent{}); err != nil {
+		return nil, err
+	}
+	return &sqlStore{db: db}, nil
+}
+
+func (s *sqlStore) SaveSign(ctx context.Context, rec *SignRecord) error {
+	// a backfilled height may be recorded twice, keep the latest status.
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"status", "time"}),
+	}).Create(rec).Error
+}
+
+func (s *sqlStore) SaveEpoch(ctx context.Context, rec *EpochRecord) error {
+	return s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"sequencer", "start_block", "end_block", "recommited", "transaction"}),
+	}).Create(rec).Error
+}
+
+func (s *sqlStore) SaveAlert(ctx context.Context, rec *AlertRecord) error {
+	return s.db.WithContext(ctx).Create(rec).Error
+}
+
+func (s *sqlStore) SaveNodeEvent(ctx context.Context, rec *NodeEvent) error {
+	return s.db.WithContext(ctx).Create(rec).Error
+}
+
+func (s *sqlStore) Signs(ctx context.Context, f Filter) ([]*SignRecord, error) {
+	var recs []*SignRecord
+	err := s.query(ctx, f, true).Order("height desc").Find(&recs).Error
+	return recs, err
+}
+
+func (s *sqlStore) Epochs(ctx context.Context, f Filter) ([]*EpochRecord, error) {
+	var recs []*EpochRecord
+	q := s.query(ctx, f, false)
+	if f.FromHeight > 0 {
+		q = q.Where("end_block >= ?", f.FromHeight)
+	}
+	if f.ToHeight > 0 {
+		q = q.Where("start_block <= ?", f.ToHeight)
+	}
+	err := q.Order("epoch_id desc").Find(&recs).Error
+	return recs, err
+}
+
+func (s *sqlStore) Alerts(ctx context.Context, f Filter) ([]*AlertRecord, error) {
+	var recs []*AlertRecord
+	err := s.query(ctx, f, false).Order("time desc, id desc").Find(&recs).Error
+	return recs, err
+}
+
+func (s *sqlStore) NodeEvents(ctx context.Context, f Filter) ([]*NodeEvent, error) {
+	var recs []*NodeEvent
+	q := s.db.WithContext(ctx)
+	if f.ChainId != "" {
+		q = q.Where("chain_id = ?", f.ChainId)
+	}
+	if f.Node != "" {
+		q = q.Where("rpc_url = ?", f.Node)
+	}
+	err := page(timeRange(q, f), f).Order("time desc, id desc").Find(&recs).Error
+	return recs, err
+}
+
+func (s *sqlStore) query(ctx context.Context, f Filter, heights bool) *gorm.DB {
+	q := s.db.WithContext(ctx)
+	if f.ChainId != "" {
+		q = q.Where("chain_id = ?", f.ChainId)
+	}
+	if f.Sequencer != "" {
+		q = q.Where("sequencer = ?", f.Sequencer)
+	}
+	if heights && f.FromHeight > 0 {
+		q = q.Where("height >= ?", f.FromHeight)
+	}
+	if heights && f.ToHeight > 0 {
+		q = q.Where("height <= ?", f.ToHeight)
+	}
+	return page(timeRange(q, f), f)
+}
+
+func timeRange(q *gorm.DB, f Filter) *gorm.DB {
+	if !f.From.IsZero() {
+		q = q.Where("time >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		q = q.Where("time < ?", f.To)
+	}
+	return q
+}
+
+func page(q *gorm.DB, f Filter) *gorm.DB {
+	if f.Limit > 0 {
+		q = q.Limit(f.Limit)
+	}
+	if f.Offset > 0 {
+		q = q.Offset(f.Offset)
+	}
+	return q
+}
+
+func (s *sqlStore) Close() error {
+	db, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
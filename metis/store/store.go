@@ -0,0 +1,122 @@
+// Package store keeps the history of the monitor: the sign status of every sequencer at every height, the epochs
+// assigned by the sequencer-set, the alerts fired and resolved and the up/down transitions of the nodes.
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Store is a history backend. Records are only appended, except epochs which are updated when they are recommited.
+// Queries return the newest records first.
+type Store interface {
+	SaveSign(ctx context.Context, rec *SignRecord) error
+	SaveEpoch(ctx context.Context, rec *EpochRecord) error
+	SaveAlert(ctx context.Context, rec *AlertRecord) error
+	SaveNodeEvent(ctx context.Context, rec *NodeEvent) error
+
+	Signs(ctx context.Context, f Filter) ([]*SignRecord, error)
+	Epochs(ctx context.Context, f Filter) ([]*EpochRecord, error)
+	Alerts(ctx context.Context, f Filter) ([]*AlertRecord, error)
+	NodeEvents(ctx context.Context, f Filter) ([]*NodeEvent, error)
+
+	Close() error
+}
+
+// Sign statuses, from the worst to the best.
+const (
+	StatusMissed    = "missed"
+	StatusPrevote   = "prevote"
+	StatusPrecommit = "precommit"
+	StatusSigned    = "signed"
+	StatusProposed  = "proposed"
+)
+
+// SignRecord is the status of a sequencer at a height.
+type SignRecord struct {
+	Id        uint      `json:"-" gorm:"primaryKey"`
+	ChainId   string    `json:"chain_id" gorm:"size:64;uniqueIndex:idx_sign_height,priority:1"`
+	Sequencer string    `json:"sequencer" gorm:"size:128;uniqueIndex:idx_sign_height,priority:2"`
+	Address   string    `json:"address" gorm:"size:64"`
+	Height    int64     `json:"height" gorm:"uniqueIndex:idx_sign_height,priority:3"`
+	Status    string    `json:"status" gorm:"size:16"`
+	Time      time.Time `json:"time" gorm:"index"`
+}
+
+// EpochRecord is an epoch (span) of a sequencer, as reported by the sequencer-set subgraph.
+type EpochRecord struct {
+	Id          uint      `json:"-" gorm:"primaryKey"`
+	ChainId     string    `json:"chain_id" gorm:"size:64;uniqueIndex:idx_epoch,priority:1"`
+	Sequencer   string    `json:"sequencer" gorm:"size:128"`
+	Address     string    `json:"address" gorm:"size:64;uniqueIndex:idx_epoch,priority:2"`
+	EpochId     int64     `json:"epoch_id" gorm:"uniqueIndex:idx_epoch,priority:3"`
+	StartBlock  int64     `json:"start_block"`
+	EndBlock    int64     `json:"end_block"`
+	Recommited  bool      `json:"recommited"`
+	Transaction string    `json:"transaction" gorm:"size:128"`
+	Time        time.Time `json:"time" gorm:"index"`
+}
+
+// AlertRecord is an alert fired or resolved. Notified is false for alerts which were only tracked, such as silenced
+// ones.
+type AlertRecord struct {
+	Id        uint      `json:"-" gorm:"primaryKey"`
+	ChainId   string    `json:"chain_id" gorm:"size:64;index"`
+	Sequencer string    `json:"sequencer" gorm:"size:128;index"`
+	AlertId   string    `json:"alert_id" gorm:"size:255"`
+	Severity  string    `json:"severity" gorm:"size:32"`
+	Message   string    `json:"message" gorm:"type:text"`
+	Resolved  bool      `json:"resolved"`
+	Notified  bool      `json:"notified"`
+	Time      time.Time `json:"time" gorm:"index"`
+}
+
+// NodeEvent is a node going down or coming back up.
+type NodeEvent struct {
+	Id      uint      `json:"-" gorm:"primaryKey"`
+	ChainId string    `json:"chain_id" gorm:"size:64;index"`
+	RpcURL  string    `json:"rpc_url" gorm:"size:255;index"`
+	Up      bool      `json:"up"`
+	Message string    `json:"message" gorm:"type:text"`
+	Time    time.Time `json:"time" gorm:"index"`
+}
+
+// Filter selects records, zero values don't filter. Heights only apply to sign records and epochs, Node only to
+// node events.
+type Filter struct {
+	ChainId    string
+	Sequencer  string
+	Node       string
+	FromHeight int64
+	ToHeight   int64
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+}
+
+func (f Filter) matchTime(t time.Time) bool {
+	return (f.From.IsZero() || !t.Before(f.From)) && (f.To.IsZero() || t.Before(f.To))
+}
+
+func (f Filter) matchHeight(h int64) bool {
+	return (f.FromHeight == 0 || h >= f.FromHeight) && (f.ToHeight == 0 || h <= f.ToHeight)
+}
+
+func (f Filter) matchString(want, have string) bool {
+	return want == "" || want == have
+}
+
+// Open opens a backend: "mysql" with a data source name, or "file" with a directory whose files are rotated after
+// maxFileMB.
+func Open(backend, dsn, path string, maxFileMB int) (Store, error) {
+	switch backend {
+	case "mysql":
+		return NewMySQL(dsn)
+	case "file":
+		return NewFile(path, maxFileMB)
+	default:
+		return nil, fmt.Errorf("unknown history backend %q, expected mysql or file", backend)
+	}
+}
@@ -0,0 +1,178 @@
+package metis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	dash "github.com/b-harvest/metisian/metis/dashboard"
+	"net/http"
+	"sync"
+)
+
+// Supervisor runs a MetisianClient per network, with a single dashboard, api and prometheus exporter for all of them.
+// A configuration without [[networks]] is a single network.
+type Supervisor struct {
+	Clients []*MetisianClient
+
+	Ctx    context.Context
+	Cancel context.CancelFunc
+
+	updateChan chan *dash.SequencerStatus
+	logChan    chan dash.LogMessage
+
+	// config is the configuration last applied, by NewSupervisor or Reload.
+	config *Config
+}
+
+func NewSupervisor(cfg *Config) (*Supervisor, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	s := &Supervisor{
+		updateChan: make(chan *dash.SequencerStatus, 16),
+		logChan:    make(chan dash.LogMessage, 64),
+		config:     cfg,
+	}
+	s.Ctx, s.Cancel = context.WithCancel(context.Background())
+	for i, n := range cfg.NetworkConfigs() {
+		c, err := newClient(s.Ctx, n, s.updateChan)
+		if err != nil {
+			s.Cancel()
+			if len(cfg.Networks) > 0 {
+				err = fmt.Errorf("%s: %w", networkName(i, n), err)
+			}
+			return nil, err
+		}
+		s.Clients = append(s.Clients, c)
+	}
+	return s, nil
+}
+
+// Run starts the dashboard, the prometheus exporter and every client, it returns once the supervisor is cancelled.
+func (s *Supervisor) Run() {
+	cfg := s.config
+	if cfg.EnableDash {
+		for i, c := range s.Clients {
+			c.handle("/networks/" + c.ChainId)
+			// the first network is also served at the root, as when there was only one.
+			if i == 0 {
+				c.handle("")
+			}
+		}
+		dash.Handle("/networks", s.networksHandler(), dash.ReadOnly)
+		if !cfg.HideLogs {
+			// validated by NewSupervisor
+			minLevel, _ := cfg.Dashboard.MinLogLevel()
+			log.AddSink(log.SinkFunc(s.sendLog), minLevel)
+		}
+		go dash.Serve(cfg.Listen, s.updateChan, s.logChan, cfg.HideLogs, cfg.Dashboard)
+		log.Info("⚙️ starting dashboard on " + cfg.Listen)
+	} else {
+		go func() {
+			for {
+				<-s.updateChan
+			}
+		}()
+	}
+
+	if cfg.EnablePrometheus {
+		go serveMetrics(cfg.PrometheusListen, s.Clients)
+		log.Info("⚙️ starting prometheus exporter on " + cfg.PrometheusListen)
+	}
+
+	for _, c := range s.Clients {
+		go c.Run()
+	}
+	<-s.Ctx.Done()
+}
+
+// sendLog feeds the log pane of the dashboard, logs are dropped rather than holding up the logger when it falls
+// behind.
+func (s *Supervisor) sendLog(e log.Event) {
+	select {
+	case s.logChan <- dash.LogMessage{MsgType: "log", Ts: e.Time.Unix(), Msg: e.String()}:
+	default:
+	}
+}
+
+// networksHandler lists the networks, and where their api is served.
+//
+//	GET /networks
+func (s *Supervisor) networksHandler() http.Handler {
+	type network struct {
+		ChainId    string `json:"chain_id"`
+		Path       string `json:"path"`
+		Sequencers int    `json:"sequencers"`
+	}
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		networks := make([]network, 0, len(s.Clients))
+		for _, c := range s.Clients {
+			networks = append(networks, network{
+				ChainId:    c.ChainId,
+				Path:       "/networks/" + c.ChainId,
+				Sequencers: len(c.GetSequencers()),
+			})
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(writer).Encode(networks)
+	})
+}
+
+// Reload applies a new configuration to the network of every client, see MetisianClient.Reload. Networks are only
+// added or removed on restart.
+func (s *Supervisor) Reload(cfg *Config) error {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	networks := cfg.NetworkConfigs()
+
+	var errs []error
+	if len(s.config.Networks) == 0 && len(cfg.Networks) == 0 {
+		// a single network, whose chain id can't change.
+		errs = append(errs, s.Clients[0].Reload(networks[0]))
+	} else {
+		byChainId := make(map[string]*Config)
+		for _, n := range networks {
+			byChainId[n.chainId()] = n
+		}
+		for _, c := range s.Clients {
+			n := byChainId[c.ChainId]
+			if n == nil {
+				log.Warn(fmt.Sprintf("network %s was removed, it is only stopped on restart", c.ChainId))
+				continue
+			}
+			delete(byChainId, c.ChainId)
+			if err := c.Reload(n); err != nil {
+				errs = append(errs, fmt.Errorf("network %s: %w", c.ChainId, err))
+			}
+		}
+		for chainId := range byChainId {
+			log.Warn(fmt.Sprintf("network %s was added, it is only started on restart", chainId))
+		}
+	}
+
+	for _, setting := range sharedRestartOnly(s.config, cfg) {
+		log.Warn(fmt.Sprintf("%s changed, it is only applied on restart", setting))
+	}
+	s.config = cfg
+	return errors.Join(errs...)
+}
+
+// SaveOnExit saves the state of every client when metisian is stopped, it returns once all of them are saved.
+func (s *Supervisor) SaveOnExit() {
+	var wg sync.WaitGroup
+	for _, c := range s.Clients {
+		stateFile := c.config.StateFile
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			saved := make(chan interface{})
+			c.SaveOnExit(stateFile, saved)
+			<-saved
+		}()
+	}
+	wg.Wait()
+	s.Cancel()
+}
@@ -0,0 +1,222 @@
+package metis
+
+import (
+	"context"
+	"fmt"
+	"github.com/b-harvest/metisian/log"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// telegram refuses messages longer than 4096 characters
+const telegramMaxMessage = 4096
+
+const telegramHelp = `/status - signing summary of every sequencer
+/alerts - active alarms
+/nodes - rpc node health
+/epochs <name> - recent epochs of a sequencer
+/silence <name> <duration> - mute a sequencer, or every alert with "all" (ex. /silence Genesis-0 2h)`
+
+// runTelegramBot answers commands sent to the configured channel or group, until the context is cancelled. Commands
+// are only accepted from the allowed users, messages posted by a channel itself have no sender and are ignored.
+func (c *MetisianClient) runTelegramBot(ctx context.Context, cfg TeleConfig) {
+	if len(cfg.AllowedUsers) == 0 {
+		log.Warn("telegram commands are enabled but no allowed_users are configured, every command will be refused")
+	}
+	bot, err := getTelegramBot(cfg.ApiKey)
+	if err != nil {
+		log.Error(fmt.Errorf("could not start telegram bot: %w", err))
+		return
+	}
+
+	u := tgbotapi.NewUpdate(0)
+	// the long poll has to return before the 30 seconds timeout of the bot's http client.
+	u.Timeout = 25
+	updates := bot.GetUpdatesChan(u)
+	defer bot.StopReceivingUpdates()
+
+	log.Info(fmt.Sprintf("⚙️ telegram bot @%s is answering commands in %s", bot.Self.UserName, cfg.Channel))
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update := <-updates:
+			msg := update.Message
+			if msg == nil || !msg.IsCommand() || !telegramChatMatches(msg.Chat, cfg.Channel) {
+				continue
+			}
+			if msg.From == nil || !telegramUserAllowed(msg.From.ID, cfg.AllowedUsers) {
+				log.Warn(fmt.Sprintf("refused telegram command /%s from %v", msg.Command(), msg.From))
+				continue
+			}
+
+			reply := tgbotapi.NewMessage(msg.Chat.ID, truncateTelegram(c.telegramCommand(msg)))
+			reply.ReplyToMessageID = msg.MessageID
+			if _, err = bot.Send(reply); err != nil {
+				log.Error(fmt.Errorf("telegram reply: %w", err))
+			}
+		}
+	}
+}
+
+func (c *MetisianClient) telegramCommand(msg *tgbotapi.Message) string {
+	args := strings.Fields(msg.CommandArguments())
+	switch msg.Command() {
+	case "status":
+		return c.telegramStatus()
+	case "alerts":
+		return c.telegramAlerts()
+	case "nodes":
+		return c.telegramNodes()
+	case "epochs":
+		if len(args) < 1 {
+			return "usage: /epochs <name>"
+		}
+		return c.telegramEpochs(strings.Join(args, " "))
+	case "silence":
+		if len(args) < 2 {
+			return "usage: /silence <name> <duration>"
+		}
+		// sequencer names may contain spaces, the duration is always the last argument.
+		s := &Silence{
+			Sequencer: strings.Join(args[:len(args)-1], " "),
+			Duration:  args[len(args)-1],
+			CreatedBy: telegramUserName(msg.From),
+		}
+		if s.Sequencer == "all" {
+			s.Sequencer = ""
+		}
+		if err := c.addSilence(s); err != nil {
+			return err.Error()
+		}
+		return fmt.Sprintf("🔕 silenced %s (id %s)", s, s.Id)
+	default:
+		return telegramHelp
+	}
+}
+
+func (c *MetisianClient) telegramStatus() string {
+	sequencers := c.GetSequencers()
+	names := make([]string, 0, len(sequencers))
+	for name := range sequencers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		seq := sequencers[name]
+		icon := "✅"
+		switch {
+		case seq.valInfo != nil && seq.valInfo.Jailed:
+			icon = "⛔️ jailed"
+		case seq.activeAlerts > 0:
+			icon = "🚨"
+		}
+		producing := ""
+		if seq.statSeqData != nil && seq.statSeqData.IsNow {
+			producing = " 💎 producing"
+		}
+		fmt.Fprintf(&b, "%s %s%s\n  signed %.0f, proposed %.0f, missed %.0f (prevote %.0f, precommit %.0f), consecutive %.0f, alerts %d\n",
+			icon, name, producing, seq.statTotalSigns, seq.statTotalProps, seq.statTotalMiss,
+			seq.statPrevoteMiss, seq.statPrecommitMiss, seq.statConsecutiveMiss, seq.activeAlerts)
+	}
+	if c.lastBlockNum > 0 {
+		fmt.Fprintf(&b, "\nlast block %d at %s", c.lastBlockNum, c.lastBlockTime.UTC().Format(time.RFC3339))
+	}
+	if b.Len() == 0 {
+		return "no sequencers are monitored"
+	}
+	return b.String()
+}
+
+func (c *MetisianClient) telegramAlerts() string {
+	c.alarms.notifyMux.RLock()
+	defer c.alarms.notifyMux.RUnlock()
+	var b strings.Builder
+	for seq, active := range c.alarms.AllAlarms {
+		for message, since := range active {
+			fmt.Fprintf(&b, "🚨 %s (since %s)\n%s\n\n", seq, since.UTC().Format(time.RFC3339), message)
+		}
+	}
+	if b.Len() == 0 {
+		return "no active alarms"
+	}
+	return b.String()
+}
+
+func (c *MetisianClient) telegramNodes() string {
+	var b strings.Builder
+	for _, node := range c.nodes() {
+		st := node.health.snapshot()
+		state := fmt.Sprintf("🟢 up at %d", st.height)
+		switch {
+		case st.syncing:
+			state = "🐢 syncing"
+		case st.down:
+			state = "🔴 down since " + st.downSince.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(&b, "%s %s\n", state, node.RpcURL)
+		if st.lastMsg != "" {
+			fmt.Fprintf(&b, "  %s\n", st.lastMsg)
+		}
+	}
+	if b.Len() == 0 {
+		return "no nodes are configured"
+	}
+	return b.String()
+}
+
+func (c *MetisianClient) telegramEpochs(name string) string {
+	seq := c.sequencers()[name]
+	if seq == nil {
+		return fmt.Sprintf("unknown sequencer %q", name)
+	}
+	data := seq.statSeqData
+	if data == nil || len(data.Epoches) == 0 {
+		return fmt.Sprintf("no epochs known for %s", name)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "epochs of %s (producing: %t)\n", name, data.IsNow)
+	for _, e := range data.Epoches {
+		fmt.Fprintf(&b, "span %s: blocks %s - %s, recommited: %t\n", e.ID, e.StartBlock, e.EndBlock, e.Recommited)
+	}
+	return b.String()
+}
+
+// telegramChatMatches compares a chat to the configured channel, which is either a numeric chat id or a @username.
+func telegramChatMatches(chat *tgbotapi.Chat, channel string) bool {
+	if chat == nil {
+		return false
+	}
+	if id, err := strconv.ParseInt(channel, 10, 64); err == nil {
+		return chat.ID == id
+	}
+	return chat.UserName != "" && strings.EqualFold(strings.TrimPrefix(channel, "@"), chat.UserName)
+}
+
+func telegramUserAllowed(id int64, allowed []int64) bool {
+	for _, a := range allowed {
+		if a == id {
+			return true
+		}
+	}
+	return false
+}
+
+func telegramUserName(u *tgbotapi.User) string {
+	if u.UserName != "" {
+		return "telegram:@" + u.UserName
+	}
+	return fmt.Sprintf("telegram:%d", u.ID)
+}
+
+func truncateTelegram(s string) string {
+	if r := []rune(s); len(r) > telegramMaxMessage {
+		return string(r[:telegramMaxMessage-1]) + "…"
+	}
+	return s
+}
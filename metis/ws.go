@@ -3,7 +3,6 @@ package metis
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/b-harvest/metisian/log"
 	dash "github.com/b-harvest/metisian/metis/dashboard"
@@ -40,6 +39,8 @@ type StatusUpdate struct {
 	Height int64
 	Status StatusType
 	Final  bool
+	// Backfilled updates are final, their status is left out of the votes seen for the live height.
+	Backfilled bool
 }
 
 // WsReply is a trimmed down version of the JSON sent from a tendermint websocket subscription.
@@ -73,10 +74,12 @@ func (c *MetisianClient) WsRun() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	var err error
+	var client *MetisClient
 	started := time.Now()
 	for {
 		// wait until our RPC client is connected and running. We will use the same URL for the websocket
-		if c.client == nil || c.GetAnySequencer().valInfo == nil {
+		client = c.rpcClient()
+		if client == nil || c.GetAnySequencer().valInfo == nil {
 			if started.Before(time.Now().Add(-2 * time.Minute)) {
 				log.ErrorDynamicArgs("websocket client timed out waiting for a working rpc endpoint, restarting")
 				return
@@ -88,7 +91,7 @@ func (c *MetisianClient) WsRun() {
 		break
 	}
 
-	err = c.client.wsConn.SetCompressionLevel(3)
+	err = client.wsConn.SetCompressionLevel(3)
 	if err != nil {
 		log.Warn(err.Error())
 	}
@@ -101,30 +104,40 @@ func (c *MetisianClient) WsRun() {
 		for {
 			select {
 			case resultMap := <-resultChan:
+				// a reload copies the sequencers it updates, the lock keeps it from copying one half updated.
+				c.seqMux.RLock()
 				for seqName, result := range resultMap {
 					seq := c.Sequencers[seqName]
+					if seq == nil {
+						// removed by a reload
+						continue
+					}
 					update := result
 					if update.Final && update.Height%20 == 0 {
-						log.Debug(fmt.Sprintf("🧊 block %d", update.Height))
+						log.With(log.Fields{"height": update.Height}).Debug("🧊 block")
 					}
 
-					if update.Status > signState {
-						signState = update.Status
+					state := update.Status
+					if !update.Backfilled {
+						if update.Status > signState {
+							signState = update.Status
+						}
+						state = signState
 					}
 					if update.Final {
 						c.lastBlockNum = update.Height
 						c.lastBlockTime = time.Now()
 						c.lastBlockAlarm = false
-						info := getAlarms(seq.name)
-						seq.blocksResults = append([]int{int(signState)}, seq.blocksResults[:len(seq.blocksResults)-1]...)
-						if signState < 3 {
+						info := c.alarms.getAlarms(seq.name)
+						seq.blocksResults = append([]int{int(state)}, seq.blocksResults[:len(seq.blocksResults)-1]...)
+						if state < 3 {
 							warn := fmt.Sprintf("❌ warning      %20s (%s) missed block %d", seq.name, seq.Address, update.Height)
 							info += warn + "\n"
 							seq.lastError = time.Now().UTC().String() + " " + info
-							log.Warn(warn)
+							seq.logger().With(log.Fields{"height": update.Height}).Warn("❌ missed block")
 						}
 
-						switch signState {
+						switch state {
 						case Statusmissed:
 							seq.statTotalMiss += 1
 							seq.statConsecutiveMiss += 1
@@ -144,15 +157,12 @@ func (c *MetisianClient) WsRun() {
 							seq.statTotalSigns += 1
 							seq.statConsecutiveMiss = 0
 						}
-						signState = -1
-						healthyNodes := 0
-						for i := range c.Nodes {
-							if !c.Nodes[i].down {
-								healthyNodes += 1
-							}
+						c.recordSign(seq, update.Height, state)
+						if !update.Backfilled {
+							signState = -1
 						}
 
-						seq.activeAlerts = alarms.getCount(seq.name)
+						seq.activeAlerts = c.alarms.getCount(seq.name)
 
 						var (
 							epochs      []int64
@@ -168,9 +178,10 @@ func (c *MetisianClient) WsRun() {
 							isProducing = true
 						}
 						if c.EnableDash {
-							log.Debug(fmt.Sprintf("Insert event for sequencer %20s (%s)", seq.name, seq.Address))
+							seq.logger().Debug("insert dashboard event")
 							c.updateChan <- &dash.SequencerStatus{
 								MsgType:      "status",
+								ChainId:      c.ChainId,
 								Name:         seq.name,
 								Address:      seq.Address,
 								Jailed:       seq.valInfo.Jailed,
@@ -181,6 +192,7 @@ func (c *MetisianClient) WsRun() {
 								Blocks:       seq.blocksResults,
 							}
 						}
+						_ = c.statusCast.Send(seq.name)
 
 						switch {
 						case seq.valInfo.Jailed:
@@ -189,6 +201,7 @@ func (c *MetisianClient) WsRun() {
 
 					}
 				}
+				c.seqMux.RUnlock()
 			case <-ctx.Done():
 				return
 			}
@@ -198,9 +211,13 @@ func (c *MetisianClient) WsRun() {
 	voteChan := make(chan *WsReply)
 	blockChan := make(chan *WsReply)
 
-	go handleVotes(ctx, voteChan, resultChan, c.GetSequencers())
+	// the sequencers are looked up for every block, a reload may add or remove them.
+	go handleVotes(ctx, voteChan, resultChan, c.GetSequencers)
 	go func() {
-		e := handleBlocks(ctx, blockChan, resultChan, c.GetSequencers())
+		backfill := func(from, to int64) {
+			c.backfill(ctx, from, to, resultChan, c.GetSequencers())
+		}
+		e := handleBlocks(ctx, blockChan, resultChan, c.GetSequencers, c.lastBlockNum, c.wsIdleTimeout(), backfill)
 		if e != nil {
 			log.ErrorDynamicArgs("🛑", e)
 			cancel()
@@ -214,7 +231,7 @@ func (c *MetisianClient) WsRun() {
 		var msg []byte
 		var e error
 		for {
-			_, msg, e = c.client.wsConn.ReadMessage()
+			_, msg, e = client.wsConn.ReadMessage()
 			if e != nil {
 				log.Error(e)
 				cancel()
@@ -241,14 +258,14 @@ func (c *MetisianClient) WsRun() {
 
 	for _, subscribe := range []string{QueryNewBlock, QueryVote} {
 		q := fmt.Sprintf(`{"jsonrpc":"2.0","method":"subscribe","id":1,"params":{"query":"%s"}}`, subscribe)
-		err = c.client.WriteMessage(websocket.TextMessage, []byte(q))
+		err = client.WriteMessage(websocket.TextMessage, []byte(q))
 		if err != nil {
 			log.Error(err)
 			cancel()
 			break
 		}
 	}
-	log.Info(fmt.Sprintf("⚙️ watching for NewBlock and Vote events via %s", c.client.wsConn.RemoteAddr()))
+	log.Info(fmt.Sprintf("⚙️ watching for NewBlock and Vote events via %s", client.wsConn.RemoteAddr()))
 	for {
 		select {
 		case <-ctx.Done():
@@ -295,18 +312,84 @@ func (rb rawBlock) find(val string) bool {
 	return false
 }
 
+// classifyBlock determines for every sequencer whether it proposed, signed or missed a finalized block.
+func classifyBlock(b *rawBlock, sequencers map[string]*Sequencer) map[string]StatusUpdate {
+	result := make(map[string]StatusUpdate, len(sequencers))
+	for _, seq := range sequencers {
+		address := strings.TrimLeft(strings.ToUpper(seq.Address), "0X")
+		upd := StatusUpdate{
+			Height: b.Block.Header.Height.val(),
+			Status: Statusmissed,
+			Final:  true,
+		}
+
+		if b.Block.Header.ProposerAddress == address {
+			upd.Status = StatusProposed
+		} else if b.find(address) {
+			upd.Status = StatusSigned
+		}
+		result[seq.name] = upd
+	}
+	return result
+}
+
+// wsIdleTimeout is how long the websocket may go without a block, ten block times and at least a minute.
+func (c *MetisianClient) wsIdleTimeout() time.Duration {
+	if idle := 10 * c.BlockTime; idle > time.Minute {
+		return idle
+	}
+	return time.Minute
+}
+
 // handleBlocks consumes the channel for new blocks and when it sees one sends a status update. It's also
-// responsible for stalled sequencer detection and will shutdown the client if there are no blocks for a minute.
-func handleBlocks(ctx context.Context, blocks chan *WsReply, results chan map[string]StatusUpdate, sequencers map[string]*Sequencer) error {
-	live := time.NewTicker(time.Minute)
+// responsible for stalled sequencer detection and will shutdown the client if there are no blocks for idle.
+// When a block arrives after a gap following lastHeight, backfill is run with the missing heights in its own
+// goroutine, so that the websocket keeps being read. The live blocks are held until it returns, the updates are then
+// sent in height order.
+func handleBlocks(ctx context.Context, blocks chan *WsReply, results chan map[string]StatusUpdate, sequencers func() map[string]*Sequencer,
+	lastHeight int64, idle time.Duration, backfill func(from, to int64)) error {
+	live := time.NewTicker(idle)
 	defer live.Stop()
 	lastBlock := time.Now()
+
+	var (
+		// held are the live blocks received during a backfill, filled is closed once it is done.
+		held   []*rawBlock
+		filled chan struct{}
+	)
+	// handle sends the updates of a block, or starts the backfill of the gap before it and holds it.
+	handle := func(b *rawBlock) {
+		height := b.Block.Header.Height.val()
+		if lastHeight > 0 && height > lastHeight+1 {
+			from, to := lastHeight+1, height-1
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				backfill(from, to)
+			}()
+			filled = done
+			lastHeight = to
+			held = append([]*rawBlock{b}, held...)
+			return
+		}
+		if height > lastHeight {
+			lastHeight = height
+		}
+		for seqName, upd := range classifyBlock(b, sequencers()) {
+			select {
+			case results <- map[string]StatusUpdate{seqName: upd}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
 	for {
 		select {
 		case <-live.C:
-			// no block for a full minute likely means we have either a dead client.
-			if lastBlock.Before(time.Now().Add(-time.Minute)) {
-				return errors.New("websocket idle for 1 minute, exiting")
+			// no block for that long likely means we have either a dead client.
+			if lastBlock.Before(time.Now().Add(-idle)) {
+				return fmt.Errorf("websocket idle for %s, exiting", idle)
 			}
 		case block := <-blocks:
 			lastBlock = time.Now()
@@ -316,20 +399,17 @@ func handleBlocks(ctx context.Context, blocks chan *WsReply, results chan map[st
 				log.ErrorDynamicArgs("could not decode block", err)
 				continue
 			}
-			for _, seq := range sequencers {
-				address := strings.TrimLeft(strings.ToUpper(seq.Address), "0X")
-				upd := StatusUpdate{
-					Height: b.Block.Header.Height.val(),
-					Status: Statusmissed,
-					Final:  true,
-				}
-
-				if b.Block.Header.ProposerAddress == address {
-					upd.Status = StatusProposed
-				} else if b.find(address) {
-					upd.Status = StatusSigned
-				}
-				results <- map[string]StatusUpdate{seq.name: upd}
+			if filled != nil {
+				held = append(held, b)
+				continue
+			}
+			handle(b)
+		case <-filled:
+			filled = nil
+			for len(held) > 0 && filled == nil {
+				b := held[0]
+				held = held[1:]
+				handle(b)
 			}
 		case <-ctx.Done():
 			return nil
@@ -347,7 +427,7 @@ type rawVote struct {
 }
 
 // handleVotes consumes the channel for precommits and prevotes, tracking where in the process a validator is.
-func handleVotes(ctx context.Context, votes chan *WsReply, results chan map[string]StatusUpdate, sequencers map[string]*Sequencer) {
+func handleVotes(ctx context.Context, votes chan *WsReply, results chan map[string]StatusUpdate, sequencers func() map[string]*Sequencer) {
 	for {
 		select {
 		case reply := <-votes:
@@ -357,7 +437,7 @@ func handleVotes(ctx context.Context, votes chan *WsReply, results chan map[stri
 				log.Error(err)
 				continue
 			}
-			for _, seq := range sequencers {
+			for _, seq := range sequencers() {
 				address := strings.TrimLeft(strings.ToUpper(seq.Address), "0X")
 				if vote.Vote.ValidatorAddress == address {
 					upd := StatusUpdate{Height: vote.Vote.Height.val()}
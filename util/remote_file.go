@@ -14,14 +14,14 @@ type RemoteContentFile struct {
 }
 
 type RemoteContentFileURL struct {
-	url string `json:"url"`
+	Url string `json:"url"`
 }
 
 // FetchRemoteFile could is usable when you fetch github private repository file, or raw content file, etc.
 func FetchRemoteFile(url, token string) ([]string, error) {
 
 	req, _ := http.NewRequest("GET", url, nil)
-	if token == "" {
+	if token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
 	client := &http.Client{}
@@ -61,7 +61,7 @@ func FetchRemoteFile(url, token string) ([]string, error) {
 			content = string(body)
 		} else {
 			for _, childContent := range remoteUrls {
-				childContents, err := FetchRemoteFile(childContent.url, token)
+				childContents, err := FetchRemoteFile(childContent.Url, token)
 				if err != nil {
 					return nil, err
 				}